@@ -0,0 +1,78 @@
+package snowflake
+
+import "fmt"
+
+// 自定义字母表的编码, 例如避开容易混淆的字符的字典
+// 字母表中字符的位置即为对应的数值, 第一个字符表示0, 进制为字母表的长度
+type Alphabet struct {
+	chars  string
+	decode [128]byte
+}
+
+// 使用 chars 创建字母表, chars 必须是2~128个互不相同的 ASCII 字符, 否则返回 ErrInvalidAlphabet
+// chars 按 ASCII 排序时, EncodePadded 的结果按字典序排序与数值排序相同
+func NewAlphabet(chars string) (*Alphabet, error) {
+	if len(chars) < 2 || len(chars) > 128 {
+		return nil, fmt.Errorf("%w: length %d", ErrInvalidAlphabet, len(chars))
+	}
+
+	a := &Alphabet{chars: chars}
+	for i := range a.decode {
+		a.decode[i] = 0xFF
+	}
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if c >= 128 {
+			return nil, fmt.Errorf("%w: non-ASCII character at %d", ErrInvalidAlphabet, i)
+		}
+		if a.decode[c] != 0xFF {
+			return nil, fmt.Errorf("%w: duplicate character %q", ErrInvalidAlphabet, c)
+		}
+		a.decode[c] = byte(i)
+	}
+	return a, nil
+}
+
+// 与 NewAlphabet 相同, 字母表不合法时 panic, 用于初始化包级变量
+func MustAlphabet(chars string) *Alphabet {
+	a, err := NewAlphabet(chars)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// 返回字母表
+func (a *Alphabet) String() string {
+	return a.chars
+}
+
+// 进制, 即字母表的长度
+func (a *Alphabet) Base() int {
+	return len(a.chars)
+}
+
+// 编码ID, 负数的ID按位视为无符号数
+func (a *Alphabet) Encode(id ID) string {
+	return string(appendUintBase(nil, uint64(id), a.chars))
+}
+
+// 编码为固定长度, 不足时在高位补上第一个字符, 参考 ID.PaddedBase58
+func (a *Alphabet) EncodePadded(id ID) string {
+	return string(appendPadded(nil, uint64(id), a.chars))
+}
+
+// 把编码之后的ID追加到 dst
+func (a *Alphabet) Append(dst []byte, id ID) []byte {
+	return appendUintBase(dst, uint64(id), a.chars)
+}
+
+// 解析 Encode 或 EncodePadded 的结果, 包含字母表以外的字符时返回 ErrInvalidCharacter,
+// 超出64位时返回 ErrOverflow
+func (a *Alphabet) Decode(s string) (ID, error) {
+	u, err := parseUintBase([]byte(s), &a.decode, uint64(len(a.chars)), ErrInvalidCharacter)
+	if err != nil {
+		return -1, err
+	}
+	return ID(u), nil
+}
@@ -0,0 +1,149 @@
+// 在 Apache Arrow 的 int64 列中读写 snowflake ID
+//
+// Field 返回的列在元数据中记录布局, 导出到 Parquet (pqarrow 保存 Arrow schema) 之后,
+// 下游依然可以通过 LayoutOf 或者元数据中的起始时间与位移从ID中恢复时间戳:
+//
+//	unix_ns = ((id >> snowflake.time_shift) & (1<<snowflake.time_bits - 1)) * snowflake.unit + snowflake.epoch * 1000000
+//
+// 其中 snowflake.unit 为时间戳单位的纳秒数, snowflake.epoch 为起始时间的 Unix 毫秒数
+package arrowsnowflake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/ming913/snowflake"
+)
+
+// 列元数据中使用的键
+const (
+	// 完整布局的 JSON
+	MetaLayout = "snowflake.layout"
+
+	// 起始时间, Unix 毫秒数
+	MetaEpoch = "snowflake.epoch"
+
+	// 时间戳单位的纳秒数
+	MetaUnit = "snowflake.unit"
+
+	// 时间戳的位移与位数
+	MetaTimeShift = "snowflake.time_shift"
+	MetaTimeBits  = "snowflake.time_bits"
+)
+
+// 列的元数据中没有布局
+var ErrNoLayout = errors.New("arrow field has no snowflake layout")
+
+// 返回类型为 int64 并在元数据中记录布局 l 的列
+func Field(name string, l snowflake.Layout, nullable bool) arrow.Field {
+	return arrow.Field{
+		Name:     name,
+		Type:     arrow.PrimitiveTypes.Int64,
+		Nullable: nullable,
+		Metadata: Metadata(l),
+	}
+}
+
+// 返回记录布局 l 的列元数据
+func Metadata(l snowflake.Layout) arrow.Metadata {
+	spec, _ := json.Marshal(l)
+
+	unit := l.Unit
+	if unit == 0 {
+		unit = time.Millisecond
+	}
+	shift := l.TenantBits + l.MachineBits + l.StepBits + l.EntropyBits
+
+	return arrow.NewMetadata(
+		[]string{MetaLayout, MetaEpoch, MetaUnit, MetaTimeShift, MetaTimeBits},
+		[]string{
+			string(spec),
+			strconv.FormatInt(l.Epoch, 10),
+			strconv.FormatInt(int64(unit), 10),
+			strconv.Itoa(int(shift)),
+			strconv.Itoa(timeBits(l)),
+		},
+	)
+}
+
+// 时间戳实际使用的位数
+func timeBits(l snowflake.Layout) int {
+	if l.TimeBits > 0 {
+		return int(l.TimeBits)
+	}
+	total := 63
+	if l.Unsigned {
+		total = 64
+	}
+	return total - int(l.VersionBits+l.TenantBits+l.MachineBits+l.StepBits+l.EntropyBits)
+}
+
+// 读取列元数据中记录的布局, 没有记录时返回 ErrNoLayout
+func LayoutOf(f arrow.Field) (snowflake.Layout, error) {
+	i := f.Metadata.FindKey(MetaLayout)
+	if i < 0 {
+		return snowflake.Layout{}, ErrNoLayout
+	}
+
+	var l snowflake.Layout
+	if err := json.Unmarshal([]byte(f.Metadata.Values()[i]), &l); err != nil {
+		return snowflake.Layout{}, fmt.Errorf("decode %s of field %q: %w", MetaLayout, f.Name, err)
+	}
+	return l, l.Validate()
+}
+
+// 使用 mem 创建包含 ids 的 int64 数组, 调用者负责 Release
+func NewArray(mem memory.Allocator, ids []snowflake.ID) *array.Int64 {
+	b := array.NewInt64Builder(mem)
+	defer b.Release()
+
+	b.Reserve(len(ids))
+	for _, id := range ids {
+		b.UnsafeAppend(int64(id))
+	}
+	return b.NewInt64Array()
+}
+
+// 使用 mem 创建包含 ids 的 int64 数组, Valid 为 false 的ID写入为 null, 调用者负责 Release
+func NewNullArray(mem memory.Allocator, ids []snowflake.NullID) *array.Int64 {
+	b := array.NewInt64Builder(mem)
+	defer b.Release()
+
+	b.Reserve(len(ids))
+	for _, id := range ids {
+		if id.Valid {
+			b.UnsafeAppend(int64(id.ID))
+		} else {
+			b.AppendNull()
+		}
+	}
+	return b.NewInt64Array()
+}
+
+// 读取 int64 数组中的ID, null 读取为0
+func IDs(arr *array.Int64) []snowflake.ID {
+	ids := make([]snowflake.ID, arr.Len())
+	for i, v := range arr.Int64Values() {
+		if arr.IsValid(i) {
+			ids[i] = snowflake.ID(v)
+		}
+	}
+	return ids
+}
+
+// 读取 int64 数组中的ID, null 读取为 Valid 为 false 的 NullID
+func NullIDs(arr *array.Int64) []snowflake.NullID {
+	ids := make([]snowflake.NullID, arr.Len())
+	for i, v := range arr.Int64Values() {
+		if arr.IsValid(i) {
+			ids[i] = snowflake.NullID{ID: snowflake.ID(v), Valid: true}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,67 @@
+package snowflake
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// 使用指定的历史时间生成ID, 用于数据迁移时把ID排列到历史位置
+// 时间必须按非递减的顺序指定, 早于上一次指定的时间时返回 ErrBackfillOrder;
+// 只记录上一次的时间戳与自增序列, 相同时间戳的自增序列耗尽时返回 ErrSequenceExhausted
+// 时间不早于Node创建时间的前一个时间单位时返回 ErrLiveTime 以免与实时生成的ID重复,
+// 前一个时间单位保留给 BorrowTime 策略, 创建之后时钟随即回退时实时生成的ID会借用这个时间戳
+// NodePool 重新创建的 Node 从被淘汰的 Node 的进度继续
+// 注意: 进度只保存在内存中, 进程重启之后可以重新指定更早的时间,
+// 不能保证与之前使用相同机器节点的进程生成的ID不重复, 需要时请自行记录已回填的时间
+func (n *Node) GenerateAt(t time.Time) (ID, error) {
+	ts := t.UnixNano() / int64(n.unit)
+
+	if ts >= n.start-1 {
+		return -1, fmt.Errorf("%w: %s", ErrLiveTime, t.Format(time.RFC3339Nano))
+	}
+	if ts < n.epoch {
+		return -1, fmt.Errorf("%w: %s is before epoch", ErrOverflow, t.Format(time.RFC3339Nano))
+	}
+
+	// 持有锁时检查, NodePool 在关闭之后读取的进度不会再变化
+	n.histMu.Lock()
+	if atomic.LoadInt32(&n.closed) != 0 {
+		n.histMu.Unlock()
+		if next, ok := n.successor(); ok {
+			return next.GenerateAt(t)
+		}
+		return -1, ErrNodeClosed
+	}
+	defer n.histMu.Unlock()
+
+	if n.fenced != nil {
+		select {
+		case <-n.fenced:
+			return -1, n.leaseErr()
+		default:
+		}
+	}
+
+	step := int64(0)
+	switch {
+	case ts < n.histLast:
+		return -1, fmt.Errorf("%w: %s", ErrBackfillOrder, t.Format(time.RFC3339Nano))
+	case ts == n.histLast:
+		step = n.histStep + 1
+		if step > n.stepMask {
+			return -1, fmt.Errorf("%w at %s", ErrSequenceExhausted, t.Format(time.RFC3339Nano))
+		}
+	}
+	n.histLast, n.histStep = ts, step
+
+	id := n.compose(ts, atomic.LoadInt64(&n.machine), 0, step)
+	if n.entropyBits > 0 {
+		e, err := randomEntropy(n.entropyMask)
+		if err != nil {
+			return -1, err
+		}
+		id |= ID(e)
+	}
+	return id, nil
+}
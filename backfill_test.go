@@ -0,0 +1,76 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGenerateAt(t *testing.T) {
+	node, err := NewNode(1, WithStepBits(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	at := time.Unix(0, Epoch*int64(time.Millisecond)).Add(time.Hour)
+	var prev ID = -1
+	for i := 0; i < 4; i++ {
+		id, err := node.GenerateAt(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= prev || node.Step(id) != int64(i) {
+			t.Fatalf("got %d with step %d, want step %d after %d", id, node.Step(id), i, prev)
+		}
+		prev = id
+	}
+	if _, err := node.GenerateAt(at); !errors.Is(err, ErrSequenceExhausted) {
+		t.Errorf("fifth ID in the same millisecond: got %v, want ErrSequenceExhausted", err)
+	}
+
+	if id, err := node.GenerateAt(at.Add(time.Millisecond)); err != nil || node.Step(id) != 0 {
+		t.Errorf("next millisecond: got (%d, %v), want step 0", id, err)
+	}
+	if _, err := node.GenerateAt(at); !errors.Is(err, ErrBackfillOrder) {
+		t.Errorf("earlier time: got %v, want ErrBackfillOrder", err)
+	}
+	if _, err := node.GenerateAt(time.Now()); !errors.Is(err, ErrLiveTime) {
+		t.Errorf("live time: got %v, want ErrLiveTime", err)
+	}
+}
+
+// NodePool 重新创建的 Node 从被淘汰的 Node 的回填进度继续, 不回填之前实时生成的时间
+func TestNodePoolGenerateAt(t *testing.T) {
+	p, err := NewNodePool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	a, err := p.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Unix(0, Epoch*int64(time.Millisecond)).Add(time.Hour)
+	first, err := a.GenerateAt(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := a.Generate()
+
+	// 淘汰之后重新创建
+	if _, err := p.Get(2); err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := b.GenerateAt(at); err != nil || id <= first {
+		t.Errorf("after re-create: got (%d, %v), want an ID after %d", id, err, first)
+	}
+	if _, err := b.GenerateAt(time.Unix(0, p.Time(live)*int64(time.Millisecond))); !errors.Is(err, ErrLiveTime) {
+		t.Errorf("time of an evicted Node's live ID: got %v, want ErrLiveTime", err)
+	}
+}
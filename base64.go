@@ -0,0 +1,110 @@
+package snowflake
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// 把8字节大端序编码为标准 Base64, 固定12个字符, 负数的ID按位视为无符号数
+// 之前的版本编码的是10进制字符串, 需要兼容旧数据时请使用 Base64Decimal
+func (f ID) Base64() string {
+	b := f.IntBytes()
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+// 解析 Base64, 与 Base64 互逆
+func ParseBase64(s string) (ID, error) {
+	u, err := parseBase64(base64.StdEncoding.Strict(), s)
+	if err != nil {
+		return -1, err
+	}
+	return ID(u), nil
+}
+
+// 把10进制字符串编码为标准 Base64, 与之前版本的 Base64 相同
+//
+// Deprecated: 结果比 Base64 长一倍以上, 只用于兼容旧数据, 请使用 Base64 或 Base64URL
+func (f ID) Base64Decimal() string {
+	return base64.StdEncoding.EncodeToString(f.Bytes())
+}
+
+// 解析 Base64Decimal 的结果
+//
+// Deprecated: 只用于兼容旧数据, 请使用 ParseBase64
+func ParseBase64Decimal(s string) (ID, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return -1, fmt.Errorf("%w: %q", ErrInvalidBase64, s)
+	}
+	return ParseBytes(b)
+}
+
+// 把8字节大端序编码为不带填充的 URL 安全 Base64, 固定11个字符, 负数的ID按位视为无符号数
+func (f ID) Base64URL() string {
+	b := f.IntBytes()
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// 解析 Base64URL, 与 Base64URL 互逆
+func ParseBase64URL(s string) (ID, error) {
+	u, err := parseBase64(base64.RawURLEncoding.Strict(), s)
+	if err != nil {
+		return -1, err
+	}
+	return ID(u), nil
+}
+
+// 把8字节大端序编码为标准 Base64, 参考 ID.Base64
+func (u UnsignedID) Base64() string {
+	b := u.IntBytes()
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+// 解析 Base64, 参考 ParseBase64
+func ParseUnsignedBase64(s string) (UnsignedID, error) {
+	u, err := parseBase64(base64.StdEncoding.Strict(), s)
+	return UnsignedID(u), err
+}
+
+// 把10进制字符串编码为标准 Base64, 与之前版本的 Base64 相同
+//
+// Deprecated: 只用于兼容旧数据, 请使用 Base64 或 Base64URL
+func (u UnsignedID) Base64Decimal() string {
+	return base64.StdEncoding.EncodeToString(u.Bytes())
+}
+
+// 解析 UnsignedID.Base64Decimal 的结果
+//
+// Deprecated: 只用于兼容旧数据, 请使用 ParseUnsignedBase64
+func ParseUnsignedBase64Decimal(s string) (UnsignedID, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidBase64, s)
+	}
+	return ParseUnsignedString(string(b))
+}
+
+// 把8字节大端序编码为不带填充的 URL 安全 Base64, 参考 ID.Base64URL
+func (u UnsignedID) Base64URL() string {
+	b := u.IntBytes()
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// 解析 Base64URL, 参考 ParseBase64URL
+func ParseUnsignedBase64URL(s string) (UnsignedID, error) {
+	u, err := parseBase64(base64.RawURLEncoding.Strict(), s)
+	return UnsignedID(u), err
+}
+
+// 使用 enc 解码8字节大端序, 长度不对时返回 ErrInvalidBase64
+// enc 应当为 Strict 模式, 否则末尾多余的位不为0的字符串也可以解析
+func parseBase64(enc *base64.Encoding, s string) (uint64, error) {
+	var b [8]byte
+	if len(s) != enc.EncodedLen(len(b)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidBase64, s)
+	}
+	if _, err := enc.Decode(b[:], []byte(s)); err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidBase64, s)
+	}
+	return uint64(ParseIntBytes(b)), nil
+}
@@ -0,0 +1,51 @@
+package snowflake
+
+import (
+	"encoding/ascii85"
+	"fmt"
+)
+
+// 把8字节大端序编码为 Ascii85, 通常为10个字符, 全0的4字节组编码为 "z"
+// 比 Base58 等编码更短, 适合长度受限的文本协议, 但包含引号与反斜杠, 用于 JSON 或 URL 时需要转义
+func (f ID) Base85() string {
+	return encodeBase85(uint64(f))
+}
+
+// 解析 Base85, 与 Base85 互逆, 解码结果不是8字节时返回 ErrInvalidBase85
+func ParseBase85(s string) (ID, error) {
+	u, err := parseBase85(s)
+	if err != nil {
+		return -1, err
+	}
+	return ID(u), nil
+}
+
+// 把8字节大端序编码为 Ascii85, 参考 ID.Base85
+func (u UnsignedID) Base85() string {
+	return encodeBase85(uint64(u))
+}
+
+// 解析 Base85, 参考 ParseBase85
+func ParseUnsignedBase85(s string) (UnsignedID, error) {
+	u, err := parseBase85(s)
+	return UnsignedID(u), err
+}
+
+func encodeBase85(v uint64) string {
+	src := UnsignedID(v).IntBytes()
+	dst := make([]byte, ascii85.MaxEncodedLen(len(src)))
+	return string(dst[:ascii85.Encode(dst, src[:])])
+}
+
+func parseBase85(s string) (uint64, error) {
+	// 8字节最多解码出8字节, 多出的空间用于检查长度
+	var dst [12]byte
+	n, _, err := ascii85.Decode(dst[:], []byte(s), true)
+	if err != nil || n != 8 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidBase85, s)
+	}
+
+	var b [8]byte
+	copy(b[:], dst[:8])
+	return uint64(ParseIntBytes(b)), nil
+}
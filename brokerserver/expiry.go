@@ -0,0 +1,98 @@
+package brokerserver
+
+import (
+	"container/heap"
+	"time"
+)
+
+// 租约的到期时间, 在 expiryHeap 中的位置由 index 记录
+type expiry struct {
+	id      int64
+	expires time.Time
+	index   int
+}
+
+// 按到期时间排序的最小堆, 实现 heap.Interface
+type expiryHeap []*expiry
+
+func (h expiryHeap) Len() int { return len(h) }
+
+func (h expiryHeap) Less(i, j int) bool { return h[i].expires.Before(h[j].expires) }
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	e := x.(*expiry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
+
+// 记录新发放或续约的租约的到期时间
+func (s *Server) track(g Grant) {
+	if e, ok := s.expiries[g.ID]; ok {
+		e.expires = g.Expires
+		heap.Fix(&s.heap, e.index)
+		return
+	}
+	e := &expiry{id: g.ID, expires: g.Expires}
+	heap.Push(&s.heap, e)
+	s.expiries[g.ID] = e
+}
+
+// 不再记录已释放的租约
+func (s *Server) untrack(id int64) {
+	if e, ok := s.expiries[id]; ok {
+		heap.Remove(&s.heap, e.index)
+		delete(s.expiries, id)
+	}
+}
+
+// 下一个可以发放的ID, 依次为已释放的ID, 从未发放过的ID与最早过期的ID, 只在 Acquire 成功之后调用 take
+// 每个ID最多被跳过一次, 均摊为常数时间
+func (s *Server) next(now time.Time) (int64, bool) {
+	for n := len(s.free); n > 0; n = len(s.free) {
+		// 释放之后可能已经作为从未发放过的ID被发放
+		id := s.free[n-1]
+		if _, ok := s.grants[id]; !ok {
+			return id, true
+		}
+		s.free = s.free[:n-1]
+	}
+
+	for s.unused <= s.max {
+		// 从加载的租约中跳过已发放的ID
+		id := (s.start + s.unused) & s.max
+		if _, ok := s.grants[id]; !ok {
+			return id, true
+		}
+		s.unused++
+	}
+
+	if len(s.heap) > 0 && !now.Before(s.heap[0].expires) {
+		return s.heap[0].id, true
+	}
+	return -1, false
+}
+
+// 把 next 返回的ID标记为已发放
+func (s *Server) take(g Grant) {
+	if n := len(s.free); n > 0 && s.free[n-1] == g.ID {
+		s.free = s.free[:n-1]
+	} else if s.unused <= s.max && (s.start+s.unused)&s.max == g.ID {
+		s.unused++
+	}
+	s.grants[g.ID] = g
+	s.track(g)
+}
@@ -0,0 +1,130 @@
+package brokerserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 租约接口的路径前缀
+const leasesPath = "/v1/leases"
+
+// 申请与续约的响应, TTL 为剩余有效期(纳秒), 客户端据此按本机时钟计算到期时间
+type grantResponse struct {
+	Grant
+	TTL time.Duration `json:"ttl"`
+}
+
+func (s *Server) response(g Grant) grantResponse {
+	return grantResponse{Grant: g, TTL: g.Expires.Sub(s.now())}
+}
+
+// 接口的请求体
+type request struct {
+	Owner string `json:"owner,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+// 提供以下接口, 请求与响应均为 JSON:
+//
+//	POST   /v1/leases       {"owner": "..."}  申请租约, 返回 Grant 与剩余有效期 ttl, 没有空闲ID时返回 503
+//	PUT    /v1/leases/{id}  {"token": "..."}  续约, 返回 Grant 与剩余有效期 ttl, 租约已失效时返回 409
+//	DELETE /v1/leases/{id}  {"token": "..."}  释放租约, 返回 204
+//	GET    /v1/leases                         当前有效的租约列表, 不包含令牌
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, leasesPath)
+	if rest == r.URL.Path {
+		http.NotFound(w, r)
+		return
+	}
+
+	if rest == "" || rest == "/" {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.Grants())
+		case http.MethodPost:
+			var req request
+			if !readJSON(w, r, &req) {
+				return
+			}
+			g, err := s.Acquire(r.Context(), req.Owner)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, s.response(g))
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, errMethodNotAllowed)
+		}
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(rest, "/"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req request
+	switch r.Method {
+	case http.MethodPut:
+		if !readJSON(w, r, &req) {
+			return
+		}
+		g, err := s.Renew(r.Context(), id, req.Token)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.response(g))
+	case http.MethodDelete:
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if err := s.Release(r.Context(), id, req.Token); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		writeError(w, errMethodNotAllowed)
+	}
+}
+
+var errMethodNotAllowed = errors.New("method not allowed")
+
+// 解析请求体, 失败时写入 400 并返回 false, 请求体为空时保留零值
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrLeaseLost):
+		code = http.StatusConflict
+	case errors.Is(err, ErrNoFreeID):
+		code = http.StatusServiceUnavailable
+	case err == errMethodNotAllowed:
+		code = http.StatusMethodNotAllowed
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
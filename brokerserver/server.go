@@ -0,0 +1,208 @@
+// 集中分配机器节点ID的服务端, 适合无法自行维护 etcd 或 ZooKeeper 的集群
+//
+// Server 持有整个机器节点ID空间, 通过 HTTP 把ID租给客户端, 客户端需要在租约到期之前续约
+// 租约通过 Store 持久化, 服务重启之后已发放的租约依然有效
+//
+//	srv, err := brokerserver.New(brokerserver.NewFileStore("leases.json"))
+//	http.ListenAndServe(":8080", srv)
+package brokerserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ming913/snowflake"
+)
+
+var (
+	// 所有机器节点ID都已被占用
+	ErrNoFreeID = errors.New("no free machine ID")
+
+	// 租约已过期或被其他客户端占用, 与 snowflake.ErrLeaseLost 相同
+	ErrLeaseLost = snowflake.ErrLeaseLost
+)
+
+// 发放给客户端的租约
+type Grant struct {
+	ID      int64     `json:"id"`
+	Token   string    `json:"token,omitempty"`
+	Owner   string    `json:"owner,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// 在 now 时是否已过期
+func (g Grant) expired(now time.Time) bool {
+	return !now.Before(g.Expires)
+}
+
+type config struct {
+	bits uint8
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// Server 的可选配置项
+type Option func(*config)
+
+// 机器节点ID使用的位数, 缺省为包级变量 snowflake.MachineBits
+func WithBits(bits uint8) Option {
+	return func(c *config) {
+		c.bits = bits
+	}
+}
+
+// 租约的有效期, 缺省为30秒
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// 分配机器节点ID的服务, 实现了 http.Handler
+type Server struct {
+	store Store
+	max   int64
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu     sync.Mutex
+	grants map[int64]Grant
+
+	// Acquire 不扫描整个ID空间: 已释放的ID, 从随机位置 start 开始依次发放的从未发放过的ID
+	// 与按到期时间排序的租约, 参考 next
+	free     []int64
+	start    int64
+	unused   int64
+	heap     expiryHeap
+	expiries map[int64]*expiry
+}
+
+// 返回一个新的 Server, 从 store 中加载已发放的租约
+func New(store Store, opts ...Option) (*Server, error) {
+	c := config{bits: snowflake.MachineBits, ttl: 30 * time.Second, now: time.Now}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.bits == 0 || c.bits > 31 {
+		return nil, fmt.Errorf("bits must be between 1 and 31")
+	}
+	if c.ttl <= 0 {
+		return nil, fmt.Errorf("ttl must be positive")
+	}
+
+	grants, err := store.Load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		store:    store,
+		max:      1<<c.bits - 1,
+		ttl:      c.ttl,
+		now:      c.now,
+		grants:   make(map[int64]Grant, len(grants)),
+		start:    randomInt63() & (1<<c.bits - 1),
+		expiries: make(map[int64]*expiry, len(grants)),
+	}
+	for _, g := range grants {
+		if g.ID >= 0 && g.ID <= s.max {
+			s.grants[g.ID] = g
+			s.track(g)
+		}
+	}
+	return s, nil
+}
+
+// 为 owner 分配一个空闲或已过期的机器节点ID, 优先使用空闲的ID, 都已占用时使用最早过期的ID
+func (s *Server) Acquire(ctx context.Context, owner string) (Grant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	id, ok := s.next(now)
+	if !ok {
+		return Grant{}, ErrNoFreeID
+	}
+
+	g := Grant{ID: id, Token: newToken(), Owner: owner, Expires: now.Add(s.ttl)}
+	if err := s.store.Save(ctx, g); err != nil {
+		return Grant{}, err
+	}
+	s.take(g)
+	return g, nil
+}
+
+// 延长租约, 租约已过期并被其他客户端占用或令牌不匹配时返回 ErrLeaseLost
+// 过期但还未被占用的租约可以续约, 客户端短暂失联之后不需要更换ID
+func (s *Server) Renew(ctx context.Context, id int64, token string) (Grant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.grants[id]
+	if !ok || g.Token != token {
+		return Grant{}, ErrLeaseLost
+	}
+
+	g.Expires = s.now().Add(s.ttl)
+	if err := s.store.Save(ctx, g); err != nil {
+		return Grant{}, err
+	}
+	s.grants[id] = g
+	s.track(g)
+	return g, nil
+}
+
+// 释放租约, 令牌不匹配时忽略
+func (s *Server) Release(ctx context.Context, id int64, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.grants[id]
+	if !ok || g.Token != token {
+		return nil
+	}
+	if err := s.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	delete(s.grants, id)
+	s.untrack(id)
+	s.free = append(s.free, id)
+	return nil
+}
+
+// 当前有效的租约, 按ID排序, 不包含令牌
+func (s *Server) Grants() []Grant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	list := make([]Grant, 0, len(s.grants))
+	for _, g := range s.grants {
+		if !g.expired(now) {
+			g.Token = ""
+			list = append(list, g)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// 生成区分租约持有者的随机令牌
+func newToken() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func randomInt63() int64 {
+	var b [8]byte
+	rand.Read(b[:])
+	return int64(binary.BigEndian.Uint64(b[:]) >> 1)
+}
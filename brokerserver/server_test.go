@@ -0,0 +1,379 @@
+package brokerserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ming913/snowflake/machineid"
+)
+
+// 可以手动推进的时间
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func withClock(c *testClock) Option {
+	return func(cfg *config) {
+		cfg.now = c.Now
+	}
+}
+
+// 发送请求并返回状态码, out 不为 nil 时解析响应, 请求失败时返回0
+// 会在多个goroutine中调用, 因此只使用 t.Error
+func call(t *testing.T, srv *httptest.Server, method, path string, body, out interface{}) int {
+	t.Helper()
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	req, err := http.NewRequest(method, srv.URL+leasesPath+path, bytes.NewReader(b))
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Error(err)
+			return 0
+		}
+	}
+	return resp.StatusCode
+}
+
+// 并发申请时每个ID只发放一次, 全部发放之后返回 503
+func TestConcurrentAcquire(t *testing.T) {
+	s, err := New(NewMemoryStore(), WithBits(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	const clients = 32
+	grants := make([]Grant, clients)
+	codes := make([]int, clients)
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = call(t, srv, http.MethodPost, "", request{Owner: "client-" + strconv.Itoa(i)}, &grants[i])
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool)
+	unavailable := 0
+	for i, code := range codes {
+		switch code {
+		case http.StatusOK:
+			if seen[grants[i].ID] {
+				t.Errorf("ID %d granted twice", grants[i].ID)
+			}
+			seen[grants[i].ID] = true
+		case http.StatusServiceUnavailable:
+			unavailable++
+		default:
+			t.Errorf("client %d: got status %d", i, code)
+		}
+	}
+	if len(seen) != 16 || unavailable != clients-16 {
+		t.Errorf("got %d grants and %d 503s, want 16 and %d", len(seen), unavailable, clients-16)
+	}
+	if n := len(s.Grants()); n != 16 {
+		t.Errorf("Grants: got %d, want 16", n)
+	}
+}
+
+func TestRenewRelease(t *testing.T) {
+	s, err := New(NewMemoryStore(), WithBits(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var g Grant
+	if code := call(t, srv, http.MethodPost, "", request{Owner: "a"}, &g); code != http.StatusOK {
+		t.Fatalf("acquire: got status %d", code)
+	}
+	path := "/" + strconv.FormatInt(g.ID, 10)
+
+	if code := call(t, srv, http.MethodPut, path, request{Token: "wrong"}, nil); code != http.StatusConflict {
+		t.Errorf("renew with wrong token: got status %d, want 409", code)
+	}
+	if code := call(t, srv, http.MethodPut, path, request{Token: g.Token}, nil); code != http.StatusOK {
+		t.Errorf("renew: got status %d, want 200", code)
+	}
+
+	// 令牌不匹配的释放被忽略
+	if code := call(t, srv, http.MethodDelete, path, request{Token: "wrong"}, nil); code != http.StatusNoContent {
+		t.Errorf("release with wrong token: got status %d, want 204", code)
+	}
+	if n := len(s.Grants()); n != 1 {
+		t.Fatalf("Grants after ignored release: got %d, want 1", n)
+	}
+
+	if code := call(t, srv, http.MethodDelete, path, request{Token: g.Token}, nil); code != http.StatusNoContent {
+		t.Errorf("release: got status %d, want 204", code)
+	}
+	if code := call(t, srv, http.MethodPut, path, request{Token: g.Token}, nil); code != http.StatusConflict {
+		t.Errorf("renew after release: got status %d, want 409", code)
+	}
+
+	var list []Grant
+	if code := call(t, srv, http.MethodGet, "", nil, &list); code != http.StatusOK || len(list) != 0 {
+		t.Errorf("list: got status %d with %d grants, want 200 with none", code, len(list))
+	}
+}
+
+// 过期的租约可以续约, 被其他客户端占用之后返回 ErrLeaseLost
+func TestExpiry(t *testing.T) {
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	s, err := New(NewMemoryStore(), WithBits(1), WithTTL(time.Minute), withClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	a, err := s.Acquire(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Acquire(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Acquire(ctx, "c"); !errors.Is(err, ErrNoFreeID) {
+		t.Fatalf("acquire with no free ID: got %v, want ErrNoFreeID", err)
+	}
+
+	clock.Add(2 * time.Minute)
+	if _, err := s.Renew(ctx, a.ID, a.Token); err != nil {
+		t.Errorf("renew an expired lease that was not taken: %v", err)
+	}
+
+	c, err := s.Acquire(ctx, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != b.ID {
+		t.Fatalf("acquire: got ID %d, want the expired ID %d", c.ID, b.ID)
+	}
+	if _, err := s.Renew(ctx, b.ID, b.Token); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("renew a lease taken by another client: got %v, want ErrLeaseLost", err)
+	}
+}
+
+// 重启之后已发放的租约依然有效
+func TestFileStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leases.json")
+	ctx := context.Background()
+
+	s, err := New(NewFileStore(path), WithBits(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := s.Acquire(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	released, err := s.Acquire(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Release(ctx, released.ID, released.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = New(NewFileStore(path), WithBits(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := s.Grants()
+	if len(list) != 1 || list[0].ID != g.ID || list[0].Owner != "a" {
+		t.Fatalf("Grants after reload: got %+v, want only %d owned by a", list, g.ID)
+	}
+	if _, err := s.Renew(ctx, g.ID, g.Token); err != nil {
+		t.Errorf("renew after reload: %v", err)
+	}
+}
+
+// machineid.NewBrokerLease 并发租用, 释放之后ID归还给 Server
+func TestBrokerLease(t *testing.T) {
+	s, err := New(NewMemoryStore(), WithBits(4), WithTTL(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	const clients = 8
+	leases := make([]*machineid.Lease, clients)
+	errs := make([]error, clients)
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			leases[i], errs[i] = machineid.NewBrokerLease(context.Background(), srv.URL, machineid.WithBits(4))
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool)
+	for i, l := range leases {
+		if errs[i] != nil {
+			t.Fatalf("client %d: %v", i, errs[i])
+		}
+		if seen[l.ID()] {
+			t.Errorf("ID %d leased twice", l.ID())
+		}
+		seen[l.ID()] = true
+	}
+	if n := len(s.Grants()); n != clients {
+		t.Errorf("Grants: got %d, want %d", n, clients)
+	}
+
+	for _, l := range leases {
+		if err := l.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+	if n := len(s.Grants()); n != 0 {
+		t.Errorf("Grants after Close: got %d, want 0", n)
+	}
+}
+
+// 依次发放已释放的ID, 从未发放过的ID与最早过期的ID, 续约之后按新的到期时间排序
+func TestAcquireOrder(t *testing.T) {
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	s, err := New(NewMemoryStore(), WithBits(2), WithTTL(time.Minute), withClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	grants := make(map[int64]Grant)
+	for i := 0; i < 4; i++ {
+		g, err := s.Acquire(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := grants[g.ID]; ok {
+			t.Fatalf("ID %d granted twice", g.ID)
+		}
+		grants[g.ID] = g
+		clock.Add(time.Second)
+	}
+	if _, err := s.Acquire(ctx, "b"); !errors.Is(err, ErrNoFreeID) {
+		t.Fatalf("acquire with no free ID: got %v, want ErrNoFreeID", err)
+	}
+
+	var first, second Grant
+	for _, g := range grants {
+		if first.Expires.IsZero() || g.Expires.Before(first.Expires) {
+			first = g
+		}
+	}
+	for _, g := range grants {
+		if g.ID != first.ID && (second.Expires.IsZero() || g.Expires.Before(second.Expires)) {
+			second = g
+		}
+	}
+
+	// 释放的ID立即可以再次发放
+	if err := s.Release(ctx, second.ID, second.Token); err != nil {
+		t.Fatal(err)
+	}
+	g, err := s.Acquire(ctx, "b")
+	if err != nil || g.ID != second.ID {
+		t.Fatalf("acquire after release: got (%d, %v), want %d", g.ID, err, second.ID)
+	}
+
+	// 最早过期的租约续约之后, 其次过期的租约先被占用
+	clock.Add(2 * time.Minute)
+	if _, err := s.Renew(ctx, first.ID, first.Token); err != nil {
+		t.Fatal(err)
+	}
+	taken := make(map[int64]bool)
+	for i := 0; i < 3; i++ {
+		g, err := s.Acquire(ctx, "c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.ID == first.ID || taken[g.ID] {
+			t.Fatalf("acquire: got ID %d, want an expired ID other than the renewed %d", g.ID, first.ID)
+		}
+		taken[g.ID] = true
+	}
+	if _, err := s.Acquire(ctx, "c"); !errors.Is(err, ErrNoFreeID) {
+		t.Errorf("acquire with no expired ID: got %v, want ErrNoFreeID", err)
+	}
+}
+
+// 保存失败时不发放ID, 之后仍然可以发放
+func TestAcquireSaveError(t *testing.T) {
+	store := &failingStore{}
+	s, err := New(store, WithBits(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	store.fail = true
+	if _, err := s.Acquire(ctx, "a"); !errors.Is(err, errSave) {
+		t.Fatalf("got %v, want errSave", err)
+	}
+	store.fail = false
+	for i := 0; i < 2; i++ {
+		if _, err := s.Acquire(ctx, "a"); err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+	}
+}
+
+var errSave = errors.New("save failed")
+
+type failingStore struct {
+	MemoryStore
+	fail bool
+}
+
+func (f *failingStore) Save(ctx context.Context, g Grant) error {
+	if f.fail {
+		return errSave
+	}
+	return f.MemoryStore.Save(ctx, g)
+}
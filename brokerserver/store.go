@@ -0,0 +1,141 @@
+package brokerserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// 租约的持久化存储, Server 在修改内存状态之前调用, 同一时刻只有一个调用
+type Store interface {
+	// 加载所有已发放的租约, 包括已过期的
+	Load(ctx context.Context) ([]Grant, error)
+
+	// 保存新发放或续约的租约
+	Save(ctx context.Context, g Grant) error
+
+	// 删除已释放的租约
+	Delete(ctx context.Context, id int64) error
+}
+
+// 只保存在内存中的 Store, 服务重启之后所有租约失效, 客户端需要重新申请
+type MemoryStore struct{}
+
+// 返回一个 MemoryStore
+func NewMemoryStore() MemoryStore {
+	return MemoryStore{}
+}
+
+func (MemoryStore) Load(context.Context) ([]Grant, error) { return nil, nil }
+
+func (MemoryStore) Save(context.Context, Grant) error { return nil }
+
+func (MemoryStore) Delete(context.Context, int64) error { return nil }
+
+// 以 JSON 格式保存在本地文件中的 Store, 每次修改都重写整个文件
+// 先写入临时文件再重命名, 进程崩溃时不会留下不完整的文件
+type FileStore struct {
+	path string
+
+	mu     sync.Mutex
+	grants map[int64]Grant
+}
+
+// 返回保存在 path 中的 FileStore, 文件不存在时在第一次保存时创建
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (f *FileStore) Load(context.Context) ([]Grant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.grants = make(map[int64]Grant)
+	b, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Grant
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, err
+	}
+	for _, g := range list {
+		f.grants[g.ID] = g
+	}
+	return list, nil
+}
+
+func (f *FileStore) Save(_ context.Context, g Grant) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.grants == nil {
+		f.grants = make(map[int64]Grant)
+	}
+	old, ok := f.grants[g.ID]
+	f.grants[g.ID] = g
+	if err := f.flush(); err != nil {
+		if ok {
+			f.grants[g.ID] = old
+		} else {
+			delete(f.grants, g.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *FileStore) Delete(_ context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old, ok := f.grants[id]
+	if !ok {
+		return nil
+	}
+	delete(f.grants, id)
+	if err := f.flush(); err != nil {
+		f.grants[id] = old
+		return err
+	}
+	return nil
+}
+
+// 把所有租约写入文件
+func (f *FileStore) flush() error {
+	list := make([]Grant, 0, len(f.grants))
+	for _, g := range f.grants {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	b, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
@@ -0,0 +1,79 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// 为 true 时 MarshalBSONValue 编码为10进制字符串, 缺省编码为 int64
+// 编码为 int64 时在查询条件, 排序与聚合中按照数值比较, 与ID的生成顺序一致
+var BSONAsString = false
+
+// BSON 的类型编号
+const (
+	bsonString byte = 0x02
+	bsonNull   byte = 0x0A
+	bsonInt32  byte = 0x10
+	bsonInt64  byte = 0x12
+)
+
+// 实现 go.mongodb.org/mongo-driver/v2/bson.ValueMarshaler, 不需要引入 mongo-driver
+// 作为查询条件使用时同样调用, 例如 bson.M{"_id": id}
+func (f ID) MarshalBSONValue() (byte, []byte, error) {
+	if BSONAsString {
+		s := f.String()
+		b := make([]byte, 4, 4+len(s)+1)
+		binary.LittleEndian.PutUint32(b, uint32(len(s)+1))
+		b = append(b, s...)
+		return bsonString, append(b, 0), nil
+	}
+
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(f))
+	return bsonInt64, b, nil
+}
+
+// 实现 go.mongodb.org/mongo-driver/v2/bson.ValueUnmarshaler, 支持 int64, int32 与10进制字符串
+func (f *ID) UnmarshalBSONValue(typ byte, data []byte) error {
+	switch typ {
+	case bsonInt64:
+		if len(data) != 8 {
+			return fmt.Errorf("invalid BSON int64 length %d", len(data))
+		}
+		*f = ID(binary.LittleEndian.Uint64(data))
+		return nil
+	case bsonInt32:
+		if len(data) != 4 {
+			return fmt.Errorf("invalid BSON int32 length %d", len(data))
+		}
+		*f = ID(int32(binary.LittleEndian.Uint32(data)))
+		return nil
+	case bsonString:
+		if len(data) < 5 || int(binary.LittleEndian.Uint32(data)) != len(data)-4 || data[len(data)-1] != 0 {
+			return fmt.Errorf("invalid BSON string")
+		}
+		return f.UnmarshalText(data[4 : len(data)-1])
+	}
+	return fmt.Errorf("cannot unmarshal BSON type 0x%02x into snowflake.ID", typ)
+}
+
+// 实现 bson.ValueMarshaler, Valid 为 false 时为 null
+func (n NullID) MarshalBSONValue() (byte, []byte, error) {
+	if !n.Valid {
+		return bsonNull, nil, nil
+	}
+	return n.ID.MarshalBSONValue()
+}
+
+// 实现 bson.ValueUnmarshaler, null 时 Valid 为 false
+func (n *NullID) UnmarshalBSONValue(typ byte, data []byte) error {
+	if typ == bsonNull {
+		n.ID, n.Valid = 0, false
+		return nil
+	}
+	if err := n.ID.UnmarshalBSONValue(typ, data); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
@@ -0,0 +1,139 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// 实现 github.com/fxamacker/cbor/v2.Marshaler, 不需要引入 cbor
+// 编码为 CBOR 整数(主类型0或1), 按照数值大小使用最短的格式
+func (f ID) MarshalCBOR() ([]byte, error) {
+	if f >= 0 {
+		return appendCBORHead(nil, cborUint, uint64(f)), nil
+	}
+	return appendCBORHead(nil, cborNegInt, uint64(-1-f)), nil
+}
+
+// 实现 cbor.Unmarshaler, 支持整数与8字节大端序的字节串, 忽略外层的标签
+func (f *ID) UnmarshalCBOR(b []byte) error {
+	for {
+		major, arg, rest, err := parseCBORHead(b)
+		if err != nil {
+			return err
+		}
+
+		switch major {
+		case cborTag:
+			b = rest
+			continue
+		case cborUint, cborNegInt:
+			if len(rest) != 0 {
+				return fmt.Errorf("invalid CBOR integer")
+			}
+			if arg > math.MaxInt64 {
+				return fmt.Errorf("%w: CBOR integer out of int64 range", ErrOverflow)
+			}
+			if major == cborUint {
+				*f = ID(arg)
+			} else {
+				*f = ID(-1 - int64(arg))
+			}
+			return nil
+		case cborBytes:
+			if arg != 8 || len(rest) != 8 {
+				return fmt.Errorf("%w: CBOR byte string length %d", ErrInvalidBinary, len(rest))
+			}
+			*f = ID(binary.BigEndian.Uint64(rest))
+			return nil
+		}
+		return fmt.Errorf("cannot unmarshal CBOR major type %d into snowflake.ID", major)
+	}
+}
+
+// 实现 cbor.Marshaler, Valid 为 false 时为 null
+func (n NullID) MarshalCBOR() ([]byte, error) {
+	if !n.Valid {
+		return []byte{cborNull}, nil
+	}
+	return n.ID.MarshalCBOR()
+}
+
+// 实现 cbor.Unmarshaler, null 或 undefined 时 Valid 为 false
+func (n *NullID) UnmarshalCBOR(b []byte) error {
+	if len(b) == 1 && (b[0] == cborNull || b[0] == cborUndefined) {
+		n.ID, n.Valid = 0, false
+		return nil
+	}
+	if err := n.ID.UnmarshalCBOR(b); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// CBOR 的主类型与简单值
+const (
+	cborUint   byte = 0
+	cborNegInt byte = 1
+	cborBytes  byte = 2
+	cborTag    byte = 6
+
+	cborNull      byte = 0xf6
+	cborUndefined byte = 0xf7
+)
+
+// 追加主类型为 major, 参数为 arg 的头部
+func appendCBORHead(b []byte, major byte, arg uint64) []byte {
+	m := major << 5
+	switch {
+	case arg < 24:
+		return append(b, m|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(b, m|24, byte(arg))
+	case arg <= math.MaxUint16:
+		b = append(b, m|25, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-2:], uint16(arg))
+		return b
+	case arg <= math.MaxUint32:
+		b = append(b, m|26, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], uint32(arg))
+		return b
+	}
+	b = append(b, m|27, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(b[len(b)-8:], arg)
+	return b
+}
+
+// 解析头部, 返回主类型, 参数与剩余的数据
+func parseCBORHead(b []byte) (major byte, arg uint64, rest []byte, err error) {
+	if len(b) == 0 {
+		return 0, 0, nil, fmt.Errorf("empty CBOR value")
+	}
+
+	major, info := b[0]>>5, b[0]&0x1f
+	b = b[1:]
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), b, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, nil, fmt.Errorf("unsupported CBOR additional information %d", info)
+	}
+	if len(b) < size {
+		return 0, 0, nil, fmt.Errorf("truncated CBOR value")
+	}
+
+	for _, c := range b[:size] {
+		arg = arg<<8 | uint64(c)
+	}
+	return major, arg, b[size:], nil
+}
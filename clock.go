@@ -0,0 +1,27 @@
+package snowflake
+
+import "time"
+
+// 时钟源, 返回当前时间戳, 单位与 Layout.Unit 相同, 缺省为毫秒(ms)
+// Node 生成ID时所有取时间的操作都通过 Clock 完成,
+// 可替换为模拟时间或 PTP 硬件时钟等非系统时钟
+type Clock interface {
+	Now() int64
+}
+
+// 函数形式的时钟源
+type ClockFunc func() int64
+
+func (f ClockFunc) Now() int64 {
+	return f()
+}
+
+// 缺省时钟源, 使用系统时钟
+type systemClock struct {
+	unit time.Duration
+}
+
+func (c systemClock) Now() int64 {
+	// 纳秒时间戳转换为指定单位的时间戳
+	return time.Now().UnixNano() / int64(c.unit)
+}
@@ -0,0 +1,75 @@
+package snowflake
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
+// 停止生成ID并释放租约, 等价于 Shutdown(context.Background())
+func (n *Node) Close() error {
+	return n.Shutdown(context.Background())
+}
+
+// 停止生成ID, 之后的 Generate 等方法返回 ErrNodeClosed, Stream 返回的通道随之关闭
+// 通过 WithLease 设置的租约实现了 io.Closer 时(例如 machineid.Lease)同时释放租约,
+// 释放之前把当前时间单位的自增序列标记为耗尽并等待进入下一个时间单位, 以免与接手该ID的进程重复
+// 等待被 ctx 中断时返回 ctx.Err() 并且不释放租约, 可以再次调用
+func (n *Node) Shutdown(ctx context.Context) error {
+	if atomic.CompareAndSwapInt32(&n.closed, 0, 1) {
+		close(n.done)
+	}
+
+	closer, ok := n.lease.(io.Closer)
+	if !ok {
+		return nil
+	}
+	if err := n.drain(ctx); err != nil {
+		return err
+	}
+
+	var err error
+	n.closeOnce.Do(func() {
+		err = closer.Close()
+	})
+	return err
+}
+
+// 等待已关闭的 Node 生成的ID的时间单位都已过去
+func (n *Node) drain(ctx context.Context) error {
+	// 等待持有锁的调用完成
+	if n.ent != nil {
+		n.ent.mu.Lock()
+		n.ent.mu.Unlock()
+	}
+
+	// 时间戳已经用尽时不会再生成ID, 不需要等待
+	if t, err := n.exhaust(); err == nil {
+		return n.waitAfter(ctx, t)
+	}
+	return nil
+}
+
+// 停止所有子Node, 通过 WithLease 设置的租约在所有子Node停止之后释放, 参考 Node.Shutdown
+func (s *ShardedNode) Close() error {
+	var first error
+	for _, node := range s.shards {
+		if err := node.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	closer, ok := s.lease.(io.Closer)
+	if !ok {
+		return first
+	}
+	for _, node := range s.shards {
+		node.drain(context.Background())
+	}
+	s.closeOnce.Do(func() {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	})
+	return first
+}
@@ -0,0 +1,56 @@
+// 集中分配机器节点ID的服务, 参考 brokerserver 包
+//
+//	snowflake-broker -addr :8080 -bits 10 -ttl 30s -data /var/lib/snowflake/leases.json
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ming913/snowflake/brokerserver"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	bits := flag.Uint("bits", 10, "machine ID bits")
+	ttl := flag.Duration("ttl", 30*time.Second, "lease TTL")
+	data := flag.String("data", "", "file to persist leases in, leases are kept in memory when empty")
+	flag.Parse()
+
+	// 先校验范围, 转换为 uint8 时超出的高位会被丢弃
+	if *bits < 1 || *bits > 31 {
+		log.Fatalf("-bits must be between 1 and 31, got %d", *bits)
+	}
+
+	var store brokerserver.Store = brokerserver.NewMemoryStore()
+	if *data != "" {
+		store = brokerserver.NewFileStore(*data)
+	}
+
+	srv, err := brokerserver.New(store, brokerserver.WithBits(uint8(*bits)), brokerserver.WithTTL(*ttl))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hs := &http.Server{Addr: *addr, Handler: srv}
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		hs.Shutdown(ctx)
+	}()
+
+	log.Printf("snowflake broker listening on %s", *addr)
+	if err := hs.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,121 @@
+package snowflake
+
+import "fmt"
+
+// Crockford Base32 字符集, 与 ULID 相同, 不包含 I, L, O, U
+const encodeCrockfordMap = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// 校验符号, 前32个与 encodeCrockfordMap 相同
+const crockfordCheckMap = encodeCrockfordMap + "*~$=U"
+
+var decodeCrockfordMap [128]byte
+
+func init() {
+	for i := range decodeCrockfordMap {
+		decodeCrockfordMap[i] = 0xFF
+	}
+	for i := 0; i < len(encodeCrockfordMap); i++ {
+		c := encodeCrockfordMap[i]
+		decodeCrockfordMap[c] = byte(i)
+		if c >= 'A' && c <= 'Z' {
+			decodeCrockfordMap[c+'a'-'A'] = byte(i)
+		}
+	}
+
+	// 容易混淆的字符
+	for _, c := range "Oo" {
+		decodeCrockfordMap[c] = 0
+	}
+	for _, c := range "IiLl" {
+		decodeCrockfordMap[c] = 1
+	}
+}
+
+// 编码为 Crockford Base32, 负数的ID按位视为无符号数
+// 与 Base32 使用的 z-base-32 不同, 适合需要人工抄写或口述的场景
+func (f ID) Crockford() string {
+	return string(appendUintBase(nil, uint64(f), encodeCrockfordMap))
+}
+
+// 编码为 Crockford Base32 并在末尾加上校验符号
+func (f ID) CrockfordCheck() string {
+	return string(appendCrockfordCheck(appendUintBase(nil, uint64(f), encodeCrockfordMap), uint64(f)))
+}
+
+// 解析 Crockford Base32, 不区分大小写, O 视为0, I 与 L 视为1, 忽略连字符
+func ParseCrockford(s string) (ID, error) {
+	u, err := parseCrockford(s, false)
+	if err != nil {
+		return -1, err
+	}
+	return ID(u), nil
+}
+
+// 解析末尾带有校验符号的 Crockford Base32, 校验失败时返回 ErrCrockfordChecksum
+func ParseCrockfordCheck(s string) (ID, error) {
+	u, err := parseCrockford(s, true)
+	if err != nil {
+		return -1, err
+	}
+	return ID(u), nil
+}
+
+// 编码为 Crockford Base32, 参考 ID.Crockford
+func (u UnsignedID) Crockford() string {
+	return string(appendUintBase(nil, uint64(u), encodeCrockfordMap))
+}
+
+// 编码为 Crockford Base32 并在末尾加上校验符号
+func (u UnsignedID) CrockfordCheck() string {
+	return string(appendCrockfordCheck(appendUintBase(nil, uint64(u), encodeCrockfordMap), uint64(u)))
+}
+
+// 解析 Crockford Base32, 参考 ParseCrockford
+func ParseUnsignedCrockford(s string) (UnsignedID, error) {
+	u, err := parseCrockford(s, false)
+	return UnsignedID(u), err
+}
+
+// 解析末尾带有校验符号的 Crockford Base32, 参考 ParseCrockfordCheck
+func ParseUnsignedCrockfordCheck(s string) (UnsignedID, error) {
+	u, err := parseCrockford(s, true)
+	return UnsignedID(u), err
+}
+
+// 校验符号为数值对37取模
+func appendCrockfordCheck(dst []byte, v uint64) []byte {
+	return append(dst, crockfordCheckMap[v%37])
+}
+
+func parseCrockford(s string, check bool) (uint64, error) {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '-' {
+			b = append(b, s[i])
+		}
+	}
+
+	var sym byte
+	if check {
+		if len(b) < 2 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidCrockford, s)
+		}
+		sym, b = b[len(b)-1], b[:len(b)-1]
+	}
+
+	v, err := parseUintBase(b, &decodeCrockfordMap, 32, ErrInvalidCrockford)
+	if err != nil {
+		return 0, err
+	}
+
+	if check {
+		want := crockfordCheckMap[v%37]
+		if sym >= 'a' && sym <= 'z' {
+			sym -= 'a' - 'A'
+		}
+		if sym != want && !(want == '1' && (sym == 'I' || sym == 'L')) && !(want == '0' && sym == 'O') {
+			return 0, fmt.Errorf("%w: %q", ErrCrockfordChecksum, s)
+		}
+	}
+	return v, nil
+}
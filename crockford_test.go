@@ -0,0 +1,99 @@
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCrockford(t *testing.T) {
+	tests := []struct {
+		id    ID
+		plain string
+		check string
+	}{
+		{0, "0", "00"},
+		{1, "1", "11"},
+		{31, "Z", "ZZ"},
+		{32, "10", "10*"},
+		{36, "14", "14U"},
+		{37, "15", "150"},
+		{math.MaxInt64, "7ZZZZZZZZZZZZ", "7ZZZZZZZZZZZZ" + string(crockfordCheckMap[math.MaxInt64%37])},
+		// 负数按位视为无符号数
+		{-1, "FZZZZZZZZZZZZ", "FZZZZZZZZZZZZ" + string(crockfordCheckMap[math.MaxUint64%37])},
+	}
+	for _, tt := range tests {
+		if got := tt.id.Crockford(); got != tt.plain {
+			t.Errorf("%d.Crockford(): got %q, want %q", tt.id, got, tt.plain)
+		}
+		if got := tt.id.CrockfordCheck(); got != tt.check {
+			t.Errorf("%d.CrockfordCheck(): got %q, want %q", tt.id, got, tt.check)
+		}
+		if got, err := ParseCrockford(tt.plain); err != nil || got != tt.id {
+			t.Errorf("ParseCrockford(%q): got (%d, %v), want %d", tt.plain, got, err, tt.id)
+		}
+		if got, err := ParseCrockfordCheck(tt.check); err != nil || got != tt.id {
+			t.Errorf("ParseCrockfordCheck(%q): got (%d, %v), want %d", tt.check, got, err, tt.id)
+		}
+	}
+}
+
+// 不区分大小写, O 视为0, I 与 L 视为1, 忽略连字符
+func TestParseCrockfordAliases(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ID
+	}{
+		{"abc", 10<<10 | 11<<5 | 12},
+		{"ABC", 10<<10 | 11<<5 | 12},
+		{"O", 0},
+		{"o", 0},
+		{"I", 1},
+		{"i", 1},
+		{"L", 1},
+		{"l", 1},
+		{"1O", 32},
+		{"A-B-C", 10<<10 | 11<<5 | 12},
+	}
+	for _, tt := range tests {
+		if got, err := ParseCrockford(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseCrockford(%q): got (%d, %v), want %d", tt.in, got, err, tt.want)
+		}
+	}
+
+	// 校验符号同样不区分大小写并接受别名
+	for _, in := range []string{"11", "1i", "1L", "1l", "00", "0o", "10*", "14u"} {
+		if _, err := ParseCrockfordCheck(in); err != nil {
+			t.Errorf("ParseCrockfordCheck(%q): %v", in, err)
+		}
+	}
+}
+
+func TestParseCrockfordInvalid(t *testing.T) {
+	tests := []struct {
+		in    string
+		check bool
+		want  error
+	}{
+		{"", false, ErrInvalidCrockford},
+		{"-", false, ErrInvalidCrockford},
+		{"U", false, ErrInvalidCrockford},
+		{"*", false, ErrInvalidCrockford},
+		{"1!", false, ErrInvalidCrockford},
+		{"G0000000000000", false, ErrOverflow},
+		{"1", true, ErrInvalidCrockford},
+		{"12", true, ErrCrockfordChecksum},
+		{"15*", true, ErrCrockfordChecksum},
+		{"10U", true, ErrCrockfordChecksum},
+		{"1U1", true, ErrInvalidCrockford},
+	}
+	for _, tt := range tests {
+		parse := ParseCrockford
+		if tt.check {
+			parse = ParseCrockfordCheck
+		}
+		if got, err := parse(tt.in); !errors.Is(err, tt.want) || got != -1 {
+			t.Errorf("parse(%q, check=%t): got (%d, %v), want (-1, %v)", tt.in, tt.check, got, err, tt.want)
+		}
+	}
+}
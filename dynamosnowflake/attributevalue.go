@@ -0,0 +1,114 @@
+// 为 aws-sdk-go-v2 的 DynamoDB attributevalue 提供 snowflake ID 类型
+//
+// ID 与 NullID 实现 attributevalue.Marshaler 与 Unmarshaler, 写入为数字(N), 可以直接作为分区键与排序键,
+// 读取时同时接受数字与10进制字符串(S), 以兼容把ID保存为字符串的旧数据
+//
+//	type Order struct {
+//		UserID  dynamosnowflake.ID     `dynamodbav:"pk"`
+//		OrderID dynamosnowflake.ID     `dynamodbav:"sk"`
+//		Parent  dynamosnowflake.NullID `dynamodbav:"parent"`
+//	}
+//
+// 注意: 开启 EncoderOptions.UseEncodingMarshalers 时, snowflake.ID 会通过 MarshalText 写入为字符串(S),
+// 使用本包的类型可以始终写入为数字
+package dynamosnowflake
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ming913/snowflake"
+)
+
+// 与 snowflake.ID 相同, 写入 DynamoDB 时使用数字(N)
+type ID snowflake.ID
+
+// 转换为 snowflake.ID
+func (id ID) ID() snowflake.ID {
+	return snowflake.ID(id)
+}
+
+// 返回10进制字符串
+func (id ID) String() string {
+	return snowflake.ID(id).String()
+}
+
+// 实现 attributevalue.Marshaler
+func (id ID) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(int64(id), 10)}, nil
+}
+
+// 实现 attributevalue.Unmarshaler, 接受数字与10进制字符串, NULL 时为0
+func (id *ID) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberN:
+		return id.parse(v.Value)
+	case *types.AttributeValueMemberS:
+		return id.parse(v.Value)
+	case *types.AttributeValueMemberNULL:
+		*id = 0
+		return nil
+	}
+	return fmt.Errorf("cannot unmarshal DynamoDB %s into snowflake ID", typeName(av))
+}
+
+func (id *ID) parse(s string) error {
+	var n snowflake.ID
+	if err := n.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	*id = ID(n)
+	return nil
+}
+
+// 可以为空的 snowflake ID, 为空时写入 NULL
+type NullID snowflake.NullID
+
+// 转换为 snowflake.NullID
+func (n NullID) NullID() snowflake.NullID {
+	return snowflake.NullID(n)
+}
+
+// 实现 attributevalue.Marshaler
+func (n NullID) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	if !n.Valid {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	return ID(n.ID).MarshalDynamoDBAttributeValue()
+}
+
+// 实现 attributevalue.Unmarshaler, NULL 时 Valid 为 false
+func (n *NullID) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	if _, ok := av.(*types.AttributeValueMemberNULL); ok {
+		*n = NullID{}
+		return nil
+	}
+	var id ID
+	if err := id.UnmarshalDynamoDBAttributeValue(av); err != nil {
+		return err
+	}
+	*n = NullID{ID: snowflake.ID(id), Valid: true}
+	return nil
+}
+
+// 错误信息中使用的 DynamoDB 类型名
+func typeName(av types.AttributeValue) string {
+	switch av.(type) {
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	}
+	return fmt.Sprintf("%T", av)
+}
@@ -0,0 +1,11 @@
+module github.com/ming913/snowflake/dynamosnowflake
+
+// aws-sdk-go-v2 的 dynamodb v1.69 要求 Go 1.24
+go 1.24
+
+require (
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.69.1
+	github.com/ming913/snowflake v0.1.0
+)
+
+require github.com/aws/smithy-go v1.28.1 // indirect
@@ -0,0 +1,80 @@
+package snowflake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// 同一时间单位内随机数重复时最多重试的次数, 超过之后按序列耗尽处理
+const maxEntropyRetries = 8
+
+// 没有自增序列时同一时间单位内已使用的随机数
+type entropySet struct {
+	// 随机数重复导致重试的次数, 需要8字节对齐, 必须是第一个字段
+	retries uint64
+
+	mu   sync.Mutex
+	time int64
+	seen map[int64]struct{}
+}
+
+// 生成 now 使用的随机数, 调用者需要持有 ent.mu
+func (n *Node) entropy(now int64) (int64, error) {
+	if n.ent == nil {
+		return randomEntropy(n.entropyMask)
+	}
+
+	s := n.ent
+	if s.seen == nil || s.time != now {
+		s.time = now
+		s.seen = make(map[int64]struct{})
+	}
+	if int64(len(s.seen)) > n.entropyMask {
+		return 0, ErrSequenceExhausted
+	}
+
+	for i := 0; i < maxEntropyRetries; i++ {
+		e, err := randomEntropy(n.entropyMask)
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := s.seen[e]; !ok {
+			s.seen[e] = struct{}{}
+			return e, nil
+		}
+		atomic.AddUint64(&s.retries, 1)
+	}
+	return 0, ErrSequenceExhausted
+}
+
+// 随机数重复导致重试的次数, 只在 StepBits 为0时统计
+func (n *Node) EntropyRetries() uint64 {
+	if n.ent == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&n.ent.retries)
+}
+
+// 读取加密随机数并截取到 mask 的范围
+func randomEntropy(mask int64) (int64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) & uint64(mask)), nil
+}
+
+// 同一时间单位内时间戳, 租户, 机器节点与自增序列都相同的 n 个ID中至少有两个重复的概率
+// 即使用相同机器节点的多个 Node 之间随机数冲突的概率, 按照生日问题近似计算
+// 同一 Node 生成的ID会去重, 不会重复
+func (l Layout) CollisionProbability(n int) float64 {
+	if n < 2 {
+		return 0
+	}
+	space := math.Ldexp(1, int(l.EntropyBits))
+	k := float64(n)
+	return -math.Expm1(-k * (k - 1) / (2 * space))
+}
@@ -0,0 +1,79 @@
+// 在 entgo.io/ent 中使用 snowflake.ID 作为字段类型与主键
+//
+// 在 schema 中使用 Mixin 声明 snowflake ID 主键, 其他字段使用 Field:
+//
+//	func (User) Mixin() []ent.Mixin {
+//		return []ent.Mixin{entsnowflake.Mixin{}}
+//	}
+//
+//	func (User) Fields() []ent.Field {
+//		return []ent.Field{entsnowflake.Field("inviter_id")}
+//	}
+//
+// 并在创建 client 之后通过 Hook 接入 Node, 创建时没有设置ID的实体自动生成ID:
+//
+//	client.Use(entsnowflake.Hook(node))
+package entsnowflake
+
+import (
+	"context"
+	"fmt"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/mixin"
+	"github.com/ming913/snowflake"
+)
+
+// 生成ID, 由 snowflake.Node, ShardedNode 与 RingNode 实现
+type Generator interface {
+	GenerateContext(ctx context.Context) (snowflake.ID, error)
+}
+
+// 类型为 snowflake.ID 的字段, 数据库中为 bigint, 读写通过 snowflake.ID 的 Scan 与 Value
+// 需要其他设置时使用 field.Int64(name).GoType(snowflake.ID(0))
+func Field(name string) ent.Field {
+	return field.Int64(name).GoType(snowflake.ID(0))
+}
+
+// 声明类型为 snowflake.ID 且创建之后不可修改的主键 id
+type Mixin struct {
+	mixin.Schema
+}
+
+// 实现 ent.Mixin
+func (Mixin) Fields() []ent.Field {
+	return []ent.Field{
+		field.Int64("id").GoType(snowflake.ID(0)).Immutable(),
+	}
+}
+
+// 使用 snowflake ID 主键的实体生成的 Mutation 实现的方法
+type idMutation interface {
+	ID() (snowflake.ID, bool)
+	SetID(id snowflake.ID)
+}
+
+// 返回 ent 的 Hook, 创建实体时如果没有设置ID, 使用 g 生成ID
+// 主键不是 snowflake.ID 的实体不受影响, 可以直接用于 client.Use
+func Hook(g Generator) ent.Hook {
+	return func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			if !m.Op().Is(ent.OpCreate) {
+				return next.Mutate(ctx, m)
+			}
+			im, ok := m.(idMutation)
+			if !ok {
+				return next.Mutate(ctx, m)
+			}
+			if _, exists := im.ID(); !exists {
+				id, err := g.GenerateContext(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("generate snowflake ID for %s: %w", m.Type(), err)
+				}
+				im.SetID(id)
+			}
+			return next.Mutate(ctx, m)
+		})
+	}
+}
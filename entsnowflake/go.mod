@@ -0,0 +1,9 @@
+module github.com/ming913/snowflake/entsnowflake
+
+// ent v0.14 要求 Go 1.24
+go 1.24
+
+require (
+	entgo.io/ent v0.14.6
+	github.com/ming913/snowflake v0.1.0
+)
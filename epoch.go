@@ -0,0 +1,24 @@
+package snowflake
+
+import "time"
+
+// 常用的时间戳起始时间, 单位: 毫秒(ms), 通过 WithEpoch 使用, 例如 WithEpoch(TwitterEpoch)
+// 另外还有 SonyflakeEpoch 与 InstagramEpoch
+const (
+	// 包级变量 Epoch 的缺省值: 2019-03-22T18:30:00 +0800
+	DefaultEpoch int64 = 1553248800000
+
+	// Twitter snowflake: 2010-11-04T01:42:54.657Z
+	TwitterEpoch int64 = 1288834974657
+
+	// Discord: 2015-01-01T00:00:00Z
+	DiscordEpoch int64 = 1420070400000
+
+	// Unix 时间: 1970-01-01T00:00:00Z, ID中的时间戳即 Unix 毫秒时间戳
+	UnixEpoch int64 = 0
+)
+
+// 使用 t 作为时间戳起始时间, 精确到毫秒
+func WithEpochTime(t time.Time) Option {
+	return WithEpoch(t.UnixNano() / int64(time.Millisecond))
+}
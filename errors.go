@@ -0,0 +1,94 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 预定义错误, 返回的错误可能附带上下文信息, 请使用 errors.Is 判断
+var (
+	// 机器节点ID超出配置的位数范围
+	ErrMachineIDOutOfRange = errors.New("machine ID out of range")
+
+	// Registry 中没有ID的格式版本对应的布局
+	ErrUnknownVersion = errors.New("unknown layout version")
+
+	// 租户超出配置的位数范围
+	ErrTenantOutOfRange = errors.New("tenant out of range")
+
+	// 机器时间回退
+	ErrClockBackwards = errors.New("clock moved backwards")
+
+	// 机器时间回退超过 MaxBackwardDrift, errors.Is(err, ErrClockBackwards) 同样成立
+	ErrClockMovedBackwards = fmt.Errorf("%w beyond max drift", ErrClockBackwards)
+
+	// 当前时间单位内的自增序列耗尽
+	ErrSequenceExhausted = errors.New("sequence exhausted")
+
+	// 位数划分或起始时间等配置不合法
+	ErrInvalidLayout = errors.New("invalid layout")
+
+	// 数值超出int64或配置的位数范围
+	ErrOverflow = errors.New("overflow")
+
+	// 时间戳超出布局可以表示的范围, errors.Is(err, ErrOverflow) 同样成立
+	ErrTimestampOverflow = fmt.Errorf("%w: timestamp exhausted", ErrOverflow)
+
+	// GenerateAt 指定的时间与实时生成的ID时间范围重叠
+	ErrLiveTime = errors.New("time overlaps the live stream")
+
+	// GenerateAt 指定的时间早于上一次指定的时间
+	ErrBackfillOrder = errors.New("backfill time is before the previous one")
+
+	// NodePool 已关闭
+	ErrPoolClosed = errors.New("node pool closed")
+
+	// RingNode 的缓冲区为空
+	ErrBufferEmpty = errors.New("ring buffer empty")
+
+	// 配置项不适用于该类型的节点, 例如 RingNode 的 WithLease
+	ErrUnsupportedOption = errors.New("unsupported option")
+
+	// 节点已关闭
+	ErrNodeClosed = errors.New("node closed")
+
+	// 机器节点ID的租约已失效, 参考 machineid.Lease
+	ErrLeaseLost = errors.New("machine ID lease lost")
+
+	// 租约只能由一个 Node 持有, NodePool 与 MultiNode 不支持 WithLease
+	ErrSharedLease = errors.New("lease cannot be shared by multiple nodes")
+
+	// 包级变量在冻结之后被修改
+	ErrConfigFrozen = errors.New("package-level layout variables must not be modified after freeze")
+
+	ErrInvalidUUID = errors.New("invalid UUID")
+
+	// 二进制形式的长度不是8字节
+	ErrInvalidBinary = errors.New("invalid binary snowflake ID")
+
+	ErrInvalidHex    = errors.New("invalid hex")
+	ErrInvalidBase85 = errors.New("invalid base85")
+	ErrInvalidBase64 = errors.New("invalid base64")
+	ErrInvalidBase62 = errors.New("invalid base62")
+	ErrInvalidBase58 = errors.New("invalid base58")
+	ErrInvalidBase32 = errors.New("invalid base32")
+	ErrInvalidBase36 = errors.New("invalid base36")
+	ErrInvalidBase2  = errors.New("invalid base2")
+
+	ErrInvalidCrockford = errors.New("invalid crockford base32")
+
+	// 自定义字母表的长度不合法, 包含重复或非 ASCII 的字符
+	ErrInvalidAlphabet = errors.New("invalid alphabet")
+
+	// 字符不在自定义字母表中
+	ErrInvalidCharacter = errors.New("character not in alphabet")
+
+	// Crockford Base32 的校验符号不匹配
+	ErrCrockfordChecksum = errors.New("crockford base32 checksum mismatch")
+)
+
+type JSONSyntaxError struct{ original []byte }
+
+func (j JSONSyntaxError) Error() string {
+	return fmt.Sprintf("invalid snowflake ID %q", string(j.original))
+}
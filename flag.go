@@ -0,0 +1,50 @@
+package snowflake
+
+// 实现 flag.Value 与 pflag.Value, 可以把命令行参数直接解析到ID变量
+// 只包含数字的参数按照10进制解析, 其余按照 Base58 解析, 例如:
+//
+//	var parent snowflake.ID
+//	flag.Var(&parent, "parent-id", "parent snowflake ID, decimal or base58")
+func (f *ID) Set(s string) error {
+	if isDecimal(s) {
+		return f.UnmarshalText([]byte(s))
+	}
+
+	id, err := ParseBase58([]byte(s))
+	if err != nil {
+		return err
+	}
+	*f = id
+	return nil
+}
+
+// 实现 pflag.Value, 在帮助信息中显示的类型名称
+func (f *ID) Type() string {
+	return "snowflakeID"
+}
+
+// 实现 flag.Value, 只接受10进制数字, 参考 ID.Set
+func (u *UnsignedID) Set(s string) error {
+	return u.UnmarshalText([]byte(s))
+}
+
+// 实现 pflag.Value
+func (u *UnsignedID) Type() string {
+	return "snowflakeUnsignedID"
+}
+
+// s 是否为可选负号加10进制数字
+func isDecimal(s string) bool {
+	if len(s) > 0 && s[0] == '-' {
+		s = s[1:]
+	}
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
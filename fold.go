@@ -0,0 +1,82 @@
+package snowflake
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 忽略字母的大小写, 用于解析电话中读出或者人工输入的ID
+// Crockford Base32 与 Base36 本身不区分大小写, 直接使用 ParseCrockford 与 ParseBase36 即可
+
+// 不区分大小写解析 z-base-32, 错误与 ParseBase32 相同
+func ParseBase32Fold(b []byte) (ID, error) {
+	lower := make([]byte, len(b))
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	return ParseBase32(lower)
+}
+
+// 不区分大小写解析 Base58, 返回所有可能的ID, 按数值从小到大排列
+// Base58 中同一个字母的大小写表示不同的数值, 结果可能有多个, 请结合 Layout 等信息(例如时间戳是否合理)选择
+// 包含字母表以外的字符时返回 ErrInvalidBase58, 所有可能的ID都超出int64时返回 ErrOverflow
+func ParseBase58Fold(b []byte) ([]ID, error) {
+	if len(b) == 0 {
+		return nil, ErrInvalidBase58
+	}
+
+	// 每个字符可能的数值
+	digits := make([][]uint64, len(b))
+	for i, c := range b {
+		if c >= 128 {
+			return nil, ErrInvalidBase58
+		}
+		if d := decodeBase58Map[c]; d != 0xFF {
+			digits[i] = append(digits[i], uint64(d))
+		}
+		if s := swapCase(c); s != c && decodeBase58Map[s] != 0xFF {
+			digits[i] = append(digits[i], uint64(decodeBase58Map[s]))
+		}
+		if len(digits[i]) == 0 {
+			return nil, ErrInvalidBase58
+		}
+	}
+
+	var ids []ID
+	var walk func(i int, v uint64)
+	walk = func(i int, v uint64) {
+		if i == len(digits) {
+			ids = append(ids, ID(v))
+			return
+		}
+		for _, d := range digits[i] {
+			// 超出int64的分支不再继续
+			if v > (math.MaxInt64-d)/58 {
+				continue
+			}
+			walk(i+1, v*58+d)
+		}
+	}
+	walk(0, 0)
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrOverflow, b)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// 交换ASCII字母的大小写
+func swapCase(c byte) byte {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return c - ('a' - 'A')
+	case c >= 'A' && c <= 'Z':
+		return c + ('a' - 'A')
+	}
+	return c
+}
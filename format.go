@@ -0,0 +1,32 @@
+package snowflake
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// 实现 fmt.Formatter
+//
+//	%d %x %X %o %b  按照 int64 格式化, 支持宽度与 #, 0 等标志
+//	%s %v           10进制字符串
+//	%q              带引号的10进制字符串
+//	%+v             按照缺省配置分解, 例如 "time=2024-01-02T03:04:05.678Z machine=1 step=2"
+func (f ID) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'd', 'x', 'X', 'o', 'O', 'b':
+		fmt.Fprintf(s, fmt.FormatString(s, verb), int64(f))
+	case 'v':
+		if s.Flag('+') {
+			l := DefaultLayout()
+			p := l.Decompose(f)
+			fmt.Fprintf(s, "time=%s machine=%d step=%d",
+				l.Timestamp(f).UTC().Format("2006-01-02T15:04:05.000Z07:00"), p.Machine, p.Step)
+			return
+		}
+		fmt.Fprintf(s, fmt.FormatString(s, 's'), f.String())
+	case 's', 'q':
+		fmt.Fprintf(s, fmt.FormatString(s, verb), f.String())
+	default:
+		fmt.Fprintf(s, "%%!%c(snowflake.ID=%s)", verb, strconv.FormatInt(int64(f), 10))
+	}
+}
@@ -0,0 +1,59 @@
+package snowflake
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	freezeOnce   sync.Once
+	isFrozen     int32
+	frozen       Layout
+	frozenLayout layout
+
+	// 冻结之前按照最近一次读取的包级变量缓存的布局, 类型为 *defaultCache
+	unfrozen atomic.Value
+)
+
+type defaultCache struct {
+	spec   Layout
+	layout layout
+}
+
+// 冻结包级变量 Epoch, MachineBits, StepBits, DatacenterBits, RegionBits, TenantBits
+// 第一次调用 NewNode 时会自动冻结, 之后再修改这些变量会导致 NewNode 返回 ErrConfigFrozen,
+// 按照缺省配置解析ID的方法 ID.Time, ID.Machine, ID.Step 等继续使用冻结时的配置, 不会 panic
+func Freeze() {
+	freezeOnce.Do(func() {
+		frozen = DefaultLayout()
+		frozenLayout = newLayout(frozen)
+		atomic.StoreInt32(&isFrozen, 1)
+	})
+}
+
+// 检查包级变量在冻结之后是否被修改, 未冻结时返回nil
+func checkFrozen() error {
+	if atomic.LoadInt32(&isFrozen) == 0 {
+		return nil
+	}
+
+	if DefaultLayout() != frozen {
+		return ErrConfigFrozen
+	}
+	return nil
+}
+
+// 返回缺省配置对应的布局, 冻结之后总是返回冻结时的布局
+func defaultLayout() layout {
+	if atomic.LoadInt32(&isFrozen) != 0 {
+		return frozenLayout
+	}
+
+	spec := DefaultLayout()
+	if c, ok := unfrozen.Load().(*defaultCache); ok && c.spec == spec {
+		return c.layout
+	}
+	l := newLayout(spec)
+	unfrozen.Store(&defaultCache{spec: spec, layout: l})
+	return l
+}
@@ -1,3 +1,3 @@
 module github.com/ming913/snowflake
 
-go 1.12
+go 1.20
@@ -0,0 +1,20 @@
+package snowflake
+
+import (
+	"encoding/gob"
+	"sync"
+)
+
+var gobOnce sync.Once
+
+// 注册 ID, UnsignedID, NullID 与 Layout, 以便通过 encoding/gob 编解码 interface{} 类型的字段
+// 字段声明为具体类型时不需要注册, ID 与 UnsignedID 通过 MarshalBinary 编码为8字节
+// 注册的名称固定, 不随包路径变化, 可以多次调用
+func RegisterGob() {
+	gobOnce.Do(func() {
+		gob.RegisterName("snowflake.ID", ID(0))
+		gob.RegisterName("snowflake.UnsignedID", UnsignedID(0))
+		gob.RegisterName("snowflake.NullID", NullID{})
+		gob.RegisterName("snowflake.Layout", Layout{})
+	})
+}
@@ -0,0 +1,24 @@
+package snowflake
+
+// 实现 gorm.io/gorm/schema.GormDataTypeInterface, 不需要引入 gorm
+// 返回 gorm 的通用类型 "int", 由各数据库的方言按照64位映射为列类型,
+// 例如 PostgreSQL 与 MySQL 的 bigint, SQLite 的 integer, 读写通过 Scan 与 Value
+//
+//	type Order struct {
+//		ID     snowflake.ID `gorm:"primaryKey;autoIncrement:false"`
+//		UserID snowflake.NullID
+//	}
+func (ID) GormDataType() string {
+	return "int"
+}
+
+// 实现 gorm.io/gorm/schema.GormDataTypeInterface, 返回 "uint", MySQL 中映射为 bigint unsigned
+func (UnsignedID) GormDataType() string {
+	return "uint"
+}
+
+// 实现 gorm.io/gorm/schema.GormDataTypeInterface
+// 结构体类型无法推断位数, 直接返回各数据库都支持的列类型 "bigint"
+func (NullID) GormDataType() string {
+	return "bigint"
+}
@@ -0,0 +1,49 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// 实现 gqlgen 的 graphql.Marshaler, 不需要引入 gqlgen, 输出带引号的10进制字符串
+// 在 schema 中声明 scalar Snowflake, 并在 gqlgen.yml 中映射:
+//
+//	models:
+//	  Snowflake:
+//	    model: github.com/ming913/snowflake.ID
+func (f ID) MarshalGQL(w io.Writer) {
+	w.Write(strconv.AppendQuote(nil, f.String()))
+}
+
+// 实现 gqlgen 的 graphql.Unmarshaler, 接受字符串与数字字面量
+func (f *ID) UnmarshalGQL(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		return f.UnmarshalText([]byte(v))
+	case json.Number:
+		return f.UnmarshalText([]byte(v))
+	case int:
+		*f = ID(v)
+		return nil
+	case int32:
+		*f = ID(v)
+		return nil
+	case int64:
+		*f = ID(v)
+		return nil
+	case float64:
+		// 超过 2^53 的数字字面量已经丢失精度, 只接受可以精确表示的整数
+		if v != math.Trunc(v) {
+			return fmt.Errorf("snowflake ID %v is not an integer", v)
+		}
+		if math.Abs(v) > 1<<53 {
+			return fmt.Errorf("%w: snowflake ID %v exceeds the exact float64 range", ErrOverflow, v)
+		}
+		*f = ID(v)
+		return nil
+	}
+	return fmt.Errorf("cannot unmarshal %T into snowflake.ID", v)
+}
@@ -0,0 +1,251 @@
+// 128位的 snowflake ID (ID128), 适用于64位已经不够用的系统
+//
+// 从高到低依次为: 48位毫秒时间戳(Unix 时间, 可以使用到10889年), 32位机器节点, 48位自增序列
+// 每毫秒的自增序列从随机值开始, 同一毫秒内递增, 按字节比较即可按生成顺序排序
+package id128
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+	"time"
+
+	"github.com/ming913/snowflake"
+)
+
+const (
+	TimeBits    = 48
+	MachineBits = 32
+	StepBits    = 48
+
+	machineMax = 1<<MachineBits - 1
+	stepMax    = 1<<StepBits - 1
+
+	// 每毫秒的起始序列取 [0, 2^47) 中的随机值, 保证至少还有 2^47 个可用序列
+	stepSeedMask = 1<<(StepBits-1) - 1
+)
+
+// 与 snowflake 包相同的编解码字符集
+const (
+	encodeBase32Map = "ybndrfg8ejkmcpqxot1uwisza345h769"
+	encodeBase62Map = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// 固定长度编码之后的长度
+const (
+	base32Len = 26
+	base62Len = 22
+)
+
+var (
+	decodeBase32Map [128]byte
+	decodeBase62Map [128]byte
+)
+
+var (
+	ErrInvalidHex    = errors.New("invalid hex")
+	ErrInvalidBase32 = errors.New("invalid base32")
+	ErrInvalidBase62 = errors.New("invalid base62")
+)
+
+func init() {
+	for i := 0; i < 128; i++ {
+		decodeBase32Map[i] = 0xFF
+		decodeBase62Map[i] = 0xFF
+	}
+
+	for i := 0; i < len(encodeBase32Map); i++ {
+		decodeBase32Map[encodeBase32Map[i]] = byte(i)
+	}
+
+	for i := 0; i < len(encodeBase62Map); i++ {
+		decodeBase62Map[encodeBase62Map[i]] = byte(i)
+	}
+}
+
+// 128位 snowflake ID, 大端序
+type ID [16]byte
+
+// 128位 snowflake Node
+type Node struct {
+	mu      sync.Mutex
+	time    int64
+	step    uint64
+	machine uint32
+	clock   snowflake.Clock
+}
+
+// 缺省时钟源, 使用系统时钟, 单位: 毫秒(ms)
+type systemClock struct{}
+
+func (systemClock) Now() int64 {
+	return time.Now().UnixNano() / 1e6
+}
+
+// NewNode 的可选配置项
+type Option func(*Node)
+
+// 设置时钟源, 单位: 毫秒(ms)
+func WithClock(clock snowflake.Clock) Option {
+	return func(n *Node) {
+		n.clock = clock
+	}
+}
+
+// 返回一个新的 Node
+func NewNode(machineID int64, opts ...Option) (*Node, error) {
+	if machineID < 0 || machineID > machineMax {
+		return nil, fmt.Errorf("%w: MachineID must be between 0 and %d", snowflake.ErrMachineIDOutOfRange, int64(machineMax))
+	}
+
+	n := &Node{machine: uint32(machineID), clock: systemClock{}}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n, nil
+}
+
+// 生成唯一ID
+// 机器时间回退时继续使用上次的时间戳, 自增序列耗尽时等待下一毫秒
+func (n *Node) Generate() ID {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.clock.Now()
+	if now < n.time {
+		now = n.time
+	}
+
+	if now == n.time {
+		n.step++
+		for n.step > stepMax {
+			time.Sleep(100 * time.Microsecond)
+			if now = n.clock.Now(); now > n.time {
+				n.step = seed()
+			}
+		}
+	} else {
+		n.step = seed()
+	}
+	n.time = now
+
+	var id ID
+	binary.BigEndian.PutUint64(id[0:8], uint64(now)<<(64-TimeBits)|uint64(n.machine)>>(MachineBits-(64-TimeBits)))
+	binary.BigEndian.PutUint64(id[8:16], uint64(n.machine)<<StepBits|n.step)
+	return id
+}
+
+// 每毫秒起始的随机序列, 随机数读取失败时从0开始
+func seed() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b[:]) & stepSeedMask
+}
+
+// 时间戳, 单位: 毫秒(ms)
+func (id ID) Time() int64 {
+	return int64(binary.BigEndian.Uint64(id[0:8]) >> (64 - TimeBits))
+}
+
+func (id ID) Machine() int64 {
+	return int64(binary.BigEndian.Uint32(id[6:10]))
+}
+
+func (id ID) Step() int64 {
+	return int64(binary.BigEndian.Uint64(id[8:16]) & stepMax)
+}
+
+// 转换为16进制字符串
+func (id ID) String() string {
+	return id.Hex()
+}
+
+// 固定32个字符的16进制字符串
+func (id ID) Hex() string {
+	return hex.EncodeToString(id[:])
+}
+
+func ParseHex(s string) (ID, error) {
+	var id ID
+	if len(s) != hex.EncodedLen(len(id)) {
+		return id, ErrInvalidHex
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return id, ErrInvalidHex
+	}
+	return id, nil
+}
+
+// 固定26个字符的 z-base-32 字符串
+func (id ID) Base32() string {
+	return string(id.encode(encodeBase32Map, base32Len))
+}
+
+func ParseBase32(b []byte) (ID, error) {
+	return decode(b, &decodeBase32Map, 32, base32Len, ErrInvalidBase32)
+}
+
+// 固定22个字符的 Base62 字符串, 保持字典序
+func (id ID) Base62() string {
+	return string(id.encode(encodeBase62Map, base62Len))
+}
+
+func ParseBase62(b []byte) (ID, error) {
+	return decode(b, &decodeBase62Map, 62, base62Len, ErrInvalidBase62)
+}
+
+// 把128位整数按照 alphabet 对应的进制编码为固定长度
+func (id ID) encode(alphabet string, length int) []byte {
+	base := uint64(len(alphabet))
+	hi := binary.BigEndian.Uint64(id[0:8])
+	lo := binary.BigEndian.Uint64(id[8:16])
+
+	b := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		var r uint64
+		hi, lo, r = divmod(hi, lo, base)
+		b[i] = alphabet[r]
+	}
+	return b
+}
+
+// 128位整数除以 d, 返回商与余数
+func divmod(hi, lo, d uint64) (uint64, uint64, uint64) {
+	qlo, r := bits.Div64(hi%d, lo, d)
+	return hi / d, qlo, r
+}
+
+// 解析固定长度的编码, 超出128位范围时返回 snowflake.ErrOverflow
+func decode(b []byte, table *[128]byte, base uint64, length int, invalid error) (ID, error) {
+	var id ID
+	if len(b) != length {
+		return id, invalid
+	}
+
+	var hi, lo uint64
+	for _, c := range b {
+		if c >= 128 || table[c] == 0xFF {
+			return id, invalid
+		}
+
+		// (hi, lo) = (hi, lo) * base + d
+		lh, ll := bits.Mul64(lo, base)
+		ll, carry := bits.Add64(ll, uint64(table[c]), 0)
+		h1, h0 := bits.Mul64(hi, base)
+		h0, carry = bits.Add64(h0, lh, carry)
+		if h1 != 0 || carry != 0 {
+			return id, fmt.Errorf("%w: %q", snowflake.ErrOverflow, b)
+		}
+		hi, lo = h0, ll
+	}
+
+	binary.BigEndian.PutUint64(id[0:8], hi)
+	binary.BigEndian.PutUint64(id[8:16], lo)
+	return id, nil
+}
@@ -0,0 +1,150 @@
+package id128
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ming913/snowflake"
+)
+
+// 由测试控制的时钟
+type manualClock struct{ now int64 }
+
+func (c *manualClock) Now() int64 { return atomic.LoadInt64(&c.now) }
+
+func (c *manualClock) set(t int64) { atomic.StoreInt64(&c.now, t) }
+
+func TestNewNodeInvalid(t *testing.T) {
+	for _, machine := range []int64{-1, machineMax + 1} {
+		if _, err := NewNode(machine); !errors.Is(err, snowflake.ErrMachineIDOutOfRange) {
+			t.Errorf("NewNode(%d): got %v, want ErrMachineIDOutOfRange", machine, err)
+		}
+	}
+}
+
+func TestGenerateFields(t *testing.T) {
+	clock := &manualClock{now: 1700000000000}
+	n, err := NewNode(machineMax, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := n.Generate()
+	if got := id.Time(); got != clock.Now() {
+		t.Errorf("Time: got %d, want %d", got, clock.Now())
+	}
+	if got := id.Machine(); got != machineMax {
+		t.Errorf("Machine: got %d, want %d", got, int64(machineMax))
+	}
+	if got := id.Step(); got > stepSeedMask {
+		t.Errorf("Step: got %d, want a seed below 2^47", got)
+	}
+
+	next := n.Generate()
+	if next.Step() != id.Step()+1 {
+		t.Errorf("Step in the same millisecond: got %d, want %d", next.Step(), id.Step()+1)
+	}
+}
+
+// 同一毫秒, 下一毫秒与时钟回退时生成的ID按字节比较依次递增
+func TestGenerateOrder(t *testing.T) {
+	clock := &manualClock{now: 1700000000000}
+	n, err := NewNode(1, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := n.Generate()
+	for i, t0 := range []int64{1700000000000, 1700000000001, 1700000000001, 1699999999000, 1700000000002} {
+		clock.set(t0)
+		id := n.Generate()
+		if bytes.Compare(prev[:], id[:]) >= 0 {
+			t.Fatalf("ID %d at %d: %s is not greater than %s", i, t0, id, prev)
+		}
+		if t0 < prev.Time() && id.Time() != prev.Time() {
+			t.Errorf("clock rollback: got time %d, want the previous %d", id.Time(), prev.Time())
+		}
+		prev = id
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	n, err := NewNode(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var max ID
+	for i := range max {
+		max[i] = 0xFF
+	}
+	for _, id := range []ID{{}, max, n.Generate()} {
+		if s := id.Hex(); len(s) != 32 {
+			t.Errorf("Hex(%x): got %d characters, want 32", id[:], len(s))
+		} else if got, err := ParseHex(s); err != nil || got != id {
+			t.Errorf("ParseHex(%q): got (%x, %v), want %x", s, got[:], err, id[:])
+		}
+		if s := id.Base32(); len(s) != base32Len {
+			t.Errorf("Base32(%x): got %d characters, want %d", id[:], len(s), base32Len)
+		} else if got, err := ParseBase32([]byte(s)); err != nil || got != id {
+			t.Errorf("ParseBase32(%q): got (%x, %v), want %x", s, got[:], err, id[:])
+		}
+		if s := id.Base62(); len(s) != base62Len {
+			t.Errorf("Base62(%x): got %d characters, want %d", id[:], len(s), base62Len)
+		} else if got, err := ParseBase62([]byte(s)); err != nil || got != id {
+			t.Errorf("ParseBase62(%q): got (%x, %v), want %x", s, got[:], err, id[:])
+		}
+	}
+}
+
+// Base62 保持字典序
+func TestBase62Order(t *testing.T) {
+	clock := &manualClock{now: 1700000000000}
+	n, err := NewNode(7, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := n.Generate().Base62()
+	for i := 0; i < 100; i++ {
+		clock.set(clock.Now() + int64(i%2))
+		s := n.Generate().Base62()
+		if s <= prev {
+			t.Fatalf("%q is not greater than %q", s, prev)
+		}
+		prev = s
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string) (ID, error)
+		input string
+		want  error
+	}{
+		{"hex short", ParseHex, strings.Repeat("0", 31), ErrInvalidHex},
+		{"hex character", ParseHex, strings.Repeat("0", 31) + "g", ErrInvalidHex},
+		{"base32 short", parseBase32, strings.Repeat("y", base32Len-1), ErrInvalidBase32},
+		{"base32 character", parseBase32, strings.Repeat("y", base32Len-1) + "0", ErrInvalidBase32},
+		{"base32 non-ascii", parseBase32, strings.Repeat("y", base32Len-2) + "é", ErrInvalidBase32},
+		{"base32 overflow", parseBase32, strings.Repeat("9", base32Len), snowflake.ErrOverflow},
+		{"base62 long", parseBase62, strings.Repeat("0", base62Len+1), ErrInvalidBase62},
+		{"base62 character", parseBase62, strings.Repeat("0", base62Len-1) + "-", ErrInvalidBase62},
+		{"base62 overflow", parseBase62, strings.Repeat("z", base62Len), snowflake.ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.parse(tt.input); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func parseBase32(s string) (ID, error) { return ParseBase32([]byte(s)) }
+
+func parseBase62(s string) (ID, error) { return ParseBase62([]byte(s)) }
@@ -0,0 +1,42 @@
+package snowflake
+
+import "hash/fnv"
+
+// Instagram 分片ID方案使用的起始时间: 2011-08-24T21:07:01.721Z
+const InstagramEpoch int64 = 1314220021721
+
+// Instagram 分片ID方案的布局: 41位毫秒时间戳, 13位逻辑分片, 10位每个分片的自增序列
+// 共使用64位, 生成的ID请通过 UnsignedID 使用, 机器节点即逻辑分片
+// 与 Postgres 中 next_id() 函数的结果相同: InstagramLayout.Compose(ms, shard, nextval%1024)
+var InstagramLayout = Layout{
+	TimeBits:    41,
+	MachineBits: 13,
+	StepBits:    10,
+	Epoch:       InstagramEpoch,
+	Unsigned:    true,
+}
+
+// 逻辑分片的数量
+const InstagramShards = 1 << 13
+
+// 返回一个为逻辑分片 shardID 生成 Instagram 方案ID的 Node, 解析ID请使用 InstagramLayout
+// opts 在 InstagramLayout 之后应用
+func NewInstagramNode(shardID int64, opts ...Option) (*Node, error) {
+	return NewNode(shardID, append([]Option{WithLayout(InstagramLayout)}, opts...)...)
+}
+
+// 把应用中的数值键(例如用户ID)映射到逻辑分片, 与 Instagram 的 user_id % shards 相同
+func InstagramShard(key int64) int64 {
+	s := key % InstagramShards
+	if s < 0 {
+		s += InstagramShards
+	}
+	return s
+}
+
+// 把字符串键通过 FNV-1a 映射到逻辑分片
+func InstagramShardString(key string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int64(h.Sum32() % InstagramShards)
+}
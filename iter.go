@@ -0,0 +1,42 @@
+//go:build go1.23
+// +build go1.23
+
+package snowflake
+
+import (
+	"context"
+	"iter"
+)
+
+// 返回一个无限的ID序列, 配合 range-over-func 使用, 循环中 break 即停止生成
+// 等待与 Generate 相同, 无法继续生成时(例如 Node 已关闭)序列结束
+//
+//	for id := range node.All() {
+//		...
+//	}
+func (n *Node) All() iter.Seq[ID] {
+	return func(yield func(ID) bool) {
+		for {
+			id, err := n.generate(context.Background(), waitAlways, 0)
+			if err != nil || !yield(id) {
+				return
+			}
+		}
+	}
+}
+
+// 返回一个包含 count 个ID的序列, 无法继续生成时提前结束
+//
+//	for id := range node.Seq(100) {
+//		...
+//	}
+func (n *Node) Seq(count int) iter.Seq[ID] {
+	return func(yield func(ID) bool) {
+		for i := 0; i < count; i++ {
+			id, err := n.generate(context.Background(), waitAlways, 0)
+			if err != nil || !yield(id) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+//go:build goexperiment.jsonv2
+// +build goexperiment.jsonv2
+
+package snowflake
+
+import "encoding/json/jsontext"
+
+// 实现 encoding/json/v2 的 MarshalerTo, 输出与 MarshalJSON 相同, 直接写入 enc 而不分配内存
+func (f ID) MarshalJSONTo(enc *jsontext.Encoder) error {
+	var buf [24]byte
+	return enc.WriteValue(f.appendJSON(buf[:0]))
+}
+
+// 实现 encoding/json/v2 的 UnmarshalerFrom, 接受的格式与 UnmarshalJSON 相同
+func (f *ID) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	v, err := dec.ReadValue()
+	if err != nil {
+		return err
+	}
+	return f.UnmarshalJSON(v)
+}
+
+// 实现 encoding/json/v2 的 MarshalerTo, 参考 ID.MarshalJSONTo
+func (u UnsignedID) MarshalJSONTo(enc *jsontext.Encoder) error {
+	var buf [24]byte
+	return enc.WriteValue(u.appendJSON(buf[:0]))
+}
+
+// 实现 encoding/json/v2 的 UnmarshalerFrom, 参考 ID.UnmarshalJSONFrom
+func (u *UnsignedID) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	v, err := dec.ReadValue()
+	if err != nil {
+		return err
+	}
+	return u.UnmarshalJSON(v)
+}
+
+// 实现 encoding/json/v2 的 MarshalerTo, NULL 编码为 null
+func (n NullID) MarshalJSONTo(enc *jsontext.Encoder) error {
+	if !n.Valid {
+		return enc.WriteToken(jsontext.Null)
+	}
+	return n.ID.MarshalJSONTo(enc)
+}
+
+// 实现 encoding/json/v2 的 UnmarshalerFrom
+func (n *NullID) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	v, err := dec.ReadValue()
+	if err != nil {
+		return err
+	}
+	return n.UnmarshalJSON(v)
+}
@@ -0,0 +1,52 @@
+// 把 snowflake.Partitioner 接入 sarama 与 franz-go, 按消息键中的 snowflake ID 选择分区
+//
+//	config.Producer.Partitioner = kafkasnowflake.NewSaramaPartitioner(snowflake.DefaultLayout().PartitionByMachine())
+//
+//	client, err := kgo.NewClient(kgo.RecordPartitioner(kafkasnowflake.NewKgoPartitioner(snowflake.PartitionByHash)))
+//
+// 键缺省为10进制字符串, 与 snowflake.ID 的 String 相同, 8字节大端序的键请使用 WithKey(BinaryKey)
+// 没有键或者键不是 snowflake ID 的消息交给各个客户端的缺省哈希分区器
+package kafkasnowflake
+
+import (
+	"github.com/ming913/snowflake"
+)
+
+// 从消息键中解析 snowflake ID
+type KeyFunc func(key []byte) (snowflake.ID, error)
+
+// 解析10进制字符串的键
+func DecimalKey(key []byte) (snowflake.ID, error) {
+	var id snowflake.ID
+	err := id.UnmarshalText(key)
+	return id, err
+}
+
+// 解析8字节大端序的键, 与 snowflake.ID 的 IntBytes 相同
+func BinaryKey(key []byte) (snowflake.ID, error) {
+	var id snowflake.ID
+	err := id.UnmarshalBinary(key)
+	return id, err
+}
+
+type config struct {
+	key KeyFunc
+}
+
+// 分区器的可选配置项
+type Option func(*config)
+
+// 解析消息键的方式, 缺省为 DecimalKey
+func WithKey(fn KeyFunc) Option {
+	return func(c *config) {
+		c.key = fn
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{key: DecimalKey}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
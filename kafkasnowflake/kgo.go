@@ -0,0 +1,54 @@
+package kafkasnowflake
+
+import (
+	"github.com/ming913/snowflake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// 返回 franz-go 的分区器, 用于 kgo.RecordPartitioner
+func NewKgoPartitioner(p snowflake.Partitioner, opts ...Option) kgo.Partitioner {
+	return &kgoPartitioner{
+		config:   newConfig(opts),
+		part:     p,
+		fallback: kgo.StickyKeyPartitioner(nil),
+	}
+}
+
+type kgoPartitioner struct {
+	config
+	part     snowflake.Partitioner
+	fallback kgo.Partitioner
+}
+
+func (k *kgoPartitioner) ForTopic(topic string) kgo.TopicPartitioner {
+	return &kgoTopicPartitioner{
+		kgoPartitioner: k,
+		fallback:       k.fallback.ForTopic(topic),
+	}
+}
+
+type kgoTopicPartitioner struct {
+	*kgoPartitioner
+	fallback kgo.TopicPartitioner
+}
+
+// 相同的键必须总是映射到相同的分区, 没有键的消息使用缺省分区器的策略
+func (t *kgoTopicPartitioner) RequiresConsistency(r *kgo.Record) bool {
+	return r.Key != nil || t.fallback.RequiresConsistency(r)
+}
+
+func (t *kgoTopicPartitioner) Partition(r *kgo.Record, n int) int {
+	if r.Key != nil {
+		if id, err := t.key(r.Key); err == nil {
+			return t.part(id, n)
+		}
+	}
+	return t.fallback.Partition(r, n)
+}
+
+// 转发给缺省分区器, 没有键的消息在开始新批次时切换分区
+func (t *kgoTopicPartitioner) OnNewBatch() {
+	if nb, ok := t.fallback.(kgo.TopicPartitionerOnNewBatch); ok {
+		nb.OnNewBatch()
+	}
+}
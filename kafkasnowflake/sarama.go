@@ -0,0 +1,44 @@
+package kafkasnowflake
+
+import (
+	"github.com/IBM/sarama"
+	"github.com/ming913/snowflake"
+)
+
+// 返回 sarama 的分区器, 用于 config.Producer.Partitioner
+func NewSaramaPartitioner(p snowflake.Partitioner, opts ...Option) sarama.PartitionerConstructor {
+	c := newConfig(opts)
+	return func(topic string) sarama.Partitioner {
+		return &saramaPartitioner{
+			partition: p,
+			key:       c.key,
+			fallback:  sarama.NewHashPartitioner(topic),
+		}
+	}
+}
+
+type saramaPartitioner struct {
+	partition snowflake.Partitioner
+	key       KeyFunc
+	fallback  sarama.Partitioner
+}
+
+func (s *saramaPartitioner) Partition(msg *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if msg.Key == nil {
+		return s.fallback.Partition(msg, numPartitions)
+	}
+	b, err := msg.Key.Encode()
+	if err != nil {
+		return -1, err
+	}
+	id, err := s.key(b)
+	if err != nil {
+		return s.fallback.Partition(msg, numPartitions)
+	}
+	return int32(s.partition(id, int(numPartitions))), nil
+}
+
+// 相同的键必须总是映射到相同的分区
+func (s *saramaPartitioner) RequiresConsistency() bool {
+	return true
+}
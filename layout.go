@@ -0,0 +1,375 @@
+package snowflake
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ID的位数划分与起始时间
+// 从高到低依次为: 符号位(0), 版本, 时间戳, 租户, 机器节点(区域 + 数据中心 + worker), 自增序列, 随机数
+// 例如缺省的 41/10/12 以及 39/16/8 等非标准划分
+// MachineLast 为 true 时机器节点与自增序列交换位置, 随机数总是位于最低位
+type Layout struct {
+	// 时间戳使用的位数, 为0时使用剩余的位数: 63(Unsigned 时为64) - VersionBits - TenantBits - MachineBits - StepBits - EntropyBits
+	TimeBits uint8
+
+	// 机器节点使用的位数
+	MachineBits uint8
+
+	// 自增序列使用的位数
+	StepBits uint8
+
+	// 最低位中使用加密随机数的位数, 使ID不可枚举, 同一时间单位内依然按时间排序
+	// StepBits 为0时同一时间单位内的ID由随机数区分, Node 会对重复的随机数重试
+	EntropyBits uint8
+
+	// 租户使用的位数, 位于时间戳之后, 由 Node.GenerateForTenant 设置
+	TenantBits uint8
+
+	// 机器节点中作为区域使用的最高位位数, 例如 3 位区域 + 7 位机器节点
+	RegionBits uint8
+
+	// 机器节点中区域之后作为数据中心使用的位数, 其余低位为 worker
+	DatacenterBits uint8
+
+	// 时间戳起始时间, 单位: 毫秒(ms)
+	// Unit 大于毫秒时必须是 Unit 的整数倍
+	Epoch int64
+
+	// 时间戳的单位, 为0时为毫秒
+	// 例如 time.Second 时同样的位数可以使用更久, 适合每秒生成ID数量不多的系统
+	Unit time.Duration
+
+	// 格式版本使用的位数, 位于最高位(符号位之后), 通常为1~2位
+	// 以后修改位数划分时使用新的版本, 通过 Registry 解析新旧ID
+	VersionBits uint8
+
+	// 格式版本, 必须小于 1<<VersionBits
+	Version int64
+
+	// 机器节点位于最低位, 自增序列位于机器节点之上, 与 Sonyflake 相同
+	MachineLast bool
+
+	// 使用包括符号位在内的全部64位, 生成的ID请通过 UnsignedID 使用
+	Unsigned bool
+}
+
+// 微秒精度的布局, 51位时间戳(约71年), 10位机器节点, 2位自增序列
+// 同一毫秒内的ID按微秒排序, 适合需要更细粒度排序但每个节点并发不高的场景
+// Node.Time 与 Layout.Time 返回微秒时间戳
+var MicrosecondLayout = Layout{
+	TimeBits:    51,
+	MachineBits: 10,
+	StepBits:    2,
+	Epoch:       Epoch,
+	Unit:        time.Microsecond,
+}
+
+// 返回由包级变量组成的缺省布局
+func DefaultLayout() Layout {
+	return Layout{
+		MachineBits:    MachineBits,
+		StepBits:       StepBits,
+		TenantBits:     TenantBits,
+		RegionBits:     RegionBits,
+		DatacenterBits: DatacenterBits,
+		Epoch:          Epoch,
+	}
+}
+
+// 时间戳实际使用的位数
+func (l Layout) timeBits() int {
+	if l.TimeBits == 0 {
+		return l.totalBits() - int(l.VersionBits) - int(l.TenantBits) - int(l.MachineBits) - int(l.StepBits) - int(l.EntropyBits)
+	}
+	return int(l.TimeBits)
+}
+
+// 可以使用的总位数
+func (l Layout) totalBits() int {
+	if l.Unsigned {
+		return 64
+	}
+	return 63
+}
+
+// 校验位数划分, 不合法时返回 ErrInvalidLayout
+func (l Layout) Validate() error {
+	if tb := l.timeBits(); tb <= 0 || int(l.VersionBits)+tb+int(l.TenantBits)+int(l.MachineBits)+int(l.StepBits)+int(l.EntropyBits) > l.totalBits() {
+		return fmt.Errorf("%w: VersionBits(%d) + TimeBits(%d) + TenantBits(%d) + MachineBits(%d) + StepBits(%d) + EntropyBits(%d) must be less than or equal to %d",
+			ErrInvalidLayout, l.VersionBits, tb, l.TenantBits, l.MachineBits, l.StepBits, l.EntropyBits, l.totalBits())
+	}
+	if l.Version < 0 || l.Version >= 1<<l.VersionBits {
+		return fmt.Errorf("%w: Version must be between 0 and %d", ErrInvalidLayout, 1<<l.VersionBits-1)
+	}
+	if int(l.RegionBits)+int(l.DatacenterBits) > int(l.MachineBits) {
+		return fmt.Errorf("%w: RegionBits + DatacenterBits must be less than or equal to MachineBits", ErrInvalidLayout)
+	}
+	if l.Unit < 0 {
+		return fmt.Errorf("%w: Unit must be positive", ErrInvalidLayout)
+	}
+	if u := l.unit(); l.Epoch*int64(time.Millisecond)%int64(u) != 0 {
+		return fmt.Errorf("%w: Epoch must be a multiple of Unit(%s)", ErrInvalidLayout, u)
+	}
+	return nil
+}
+
+// 时间戳实际使用的单位
+func (l Layout) unit() time.Duration {
+	if l.Unit == 0 {
+		return time.Millisecond
+	}
+	return l.Unit
+}
+
+// 解析ID中的时间戳, 单位与 Unit 相同, 缺省为毫秒(ms)
+func (l Layout) Time(id ID) int64 {
+	return newLayout(l).time(id)
+}
+
+// 解析ID中的时间
+func (l Layout) Timestamp(id ID) time.Time {
+	return newLayout(l).timestamp(id)
+}
+
+// 解析ID中的机器节点
+func (l Layout) Machine(id ID) int64 {
+	return newLayout(l).machine(id)
+}
+
+// 解析ID中的自增序列
+func (l Layout) Step(id ID) int64 {
+	return newLayout(l).step(id)
+}
+
+// 解析ID中的格式版本
+func (l Layout) VersionOf(id ID) int64 {
+	return newLayout(l).version(id)
+}
+
+// 解析ID中的随机数
+func (l Layout) Entropy(id ID) int64 {
+	return newLayout(l).entropy(id)
+}
+
+// 解析ID中的租户
+func (l Layout) Tenant(id ID) int64 {
+	return newLayout(l).tenant(id)
+}
+
+// 解析ID中的区域
+func (l Layout) Region(id ID) int64 {
+	return newLayout(l).region(id)
+}
+
+// 解析ID中的数据中心
+func (l Layout) Datacenter(id ID) int64 {
+	return newLayout(l).datacenter(id)
+}
+
+// 解析ID中的 worker
+func (l Layout) Worker(id ID) int64 {
+	return newLayout(l).worker(id)
+}
+
+// 可以表示的最后一个时间
+func (l Layout) MaxTime() time.Time {
+	c := newLayout(l)
+	v, u := c.epoch+c.timeMask, int64(c.unit)
+
+	// 分开计算秒与纳秒, 以免时间单位较大时超出纳秒的范围
+	if u < int64(time.Second) {
+		per := int64(time.Second) / u
+		return time.Unix(v/per, v%per*u)
+	}
+	return time.Unix(v*(u/int64(time.Second)), 0)
+}
+
+// 从起始时间开始时间戳可以使用的时长, 超过 time.Duration 的范围时返回最大值
+func (l Layout) Lifetime() time.Duration {
+	c := newLayout(l)
+	if c.timeMask >= math.MaxInt64/int64(c.unit) {
+		return math.MaxInt64
+	}
+	return time.Duration(c.timeMask+1) * c.unit
+}
+
+// 可以使用的机器节点数量
+func (l Layout) MaxMachines() int64 {
+	return int64(1) << l.MachineBits
+}
+
+// 每个机器节点每个时间单位可以生成的ID数量
+func (l Layout) MaxStepsPerUnit() int64 {
+	return int64(1) << l.StepBits
+}
+
+// 每个机器节点每秒可以生成的ID数量, 时间单位大于1秒时按比例折算
+func (l Layout) MaxPerSecond() float64 {
+	return float64(l.MaxStepsPerUnit()) * float64(time.Second) / float64(l.unit())
+}
+
+// ID的各个组成部分
+type Parts struct {
+	Version    int64
+	Time       int64
+	Tenant     int64
+	Machine    int64
+	Region     int64
+	Datacenter int64
+	Worker     int64
+	Step       int64
+	Entropy    int64
+}
+
+// 按照布局分解ID
+func (l Layout) Decompose(id ID) Parts {
+	c := newLayout(l)
+	return Parts{
+		Version:    c.version(id),
+		Time:       c.time(id),
+		Tenant:     c.tenant(id),
+		Machine:    c.machine(id),
+		Region:     c.region(id),
+		Datacenter: c.datacenter(id),
+		Worker:     c.worker(id),
+		Step:       c.step(id),
+		Entropy:    c.entropy(id),
+	}
+}
+
+// 按照布局组合租户为0的ID, t 的单位与 Unit 相同, 各部分超出位数范围时返回 ErrOverflow
+func (l Layout) Compose(t, machine, step int64) (ID, error) {
+	c := newLayout(l)
+	if t < c.epoch || t-c.epoch > c.timeMask {
+		return -1, fmt.Errorf("%w: time %d out of range", ErrOverflow, t)
+	}
+	if machine < 0 || machine > c.machineMax {
+		return -1, fmt.Errorf("%w: MachineID must be between 0 and %d", ErrMachineIDOutOfRange, c.machineMax)
+	}
+	if step < 0 || step > c.stepMask {
+		return -1, fmt.Errorf("%w: step %d out of range", ErrOverflow, step)
+	}
+	return c.compose(t, machine, 0, step), nil
+}
+
+// 根据布局预先计算好的掩码与位移
+type layout struct {
+	spec Layout
+
+	// 时间戳单位以及换算为该单位的起始时间
+	unit         time.Duration
+	epoch        int64
+	timeMask     int64
+	machineMax   int64
+	stepMask     int64
+	tenantMax    int64
+	entropyMask  int64
+	entropyBits  uint8
+	versionTag   uint64
+	versionShift uint8
+	versionMax   uint64
+	stepBits     uint8
+	timeShift    uint8
+	tenantShift  uint8
+	machineShift uint8
+	stepShift    uint8
+	regionShift  uint8
+	regionMax    int64
+	workerBits   uint8
+	workerMask   int64
+	dcMax        int64
+}
+
+func newLayout(l Layout) layout {
+	if tb := l.timeBits(); l.TimeBits == 0 && tb > 0 {
+		l.TimeBits = uint8(tb)
+	}
+
+	c := layout{
+		spec:         l,
+		unit:         l.unit(),
+		epoch:        l.Epoch * int64(time.Millisecond) / int64(l.unit()),
+		timeMask:     1<<l.TimeBits - 1,
+		machineMax:   1<<l.MachineBits - 1,
+		stepMask:     1<<l.StepBits - 1,
+		tenantMax:    1<<l.TenantBits - 1,
+		entropyMask:  1<<l.EntropyBits - 1,
+		entropyBits:  l.EntropyBits,
+		versionShift: uint8(l.totalBits()) - l.VersionBits,
+		versionMax:   1<<l.VersionBits - 1,
+		stepBits:     l.StepBits,
+		timeShift:    l.TenantBits + l.MachineBits + l.StepBits + l.EntropyBits,
+		tenantShift:  l.MachineBits + l.StepBits + l.EntropyBits,
+		machineShift: l.StepBits + l.EntropyBits,
+		stepShift:    l.EntropyBits,
+		regionShift:  l.MachineBits - l.RegionBits,
+		regionMax:    1<<l.RegionBits - 1,
+		workerBits:   l.MachineBits - l.RegionBits - l.DatacenterBits,
+		workerMask:   1<<(l.MachineBits-l.RegionBits-l.DatacenterBits) - 1,
+		dcMax:        1<<l.DatacenterBits - 1,
+	}
+	if l.VersionBits > 0 {
+		c.versionTag = uint64(l.Version) << c.versionShift
+	}
+	if l.MachineLast {
+		c.machineShift = l.EntropyBits
+		c.stepShift = l.MachineBits + l.EntropyBits
+	}
+	return c
+}
+
+// 通过位移把数据放到指定位置, 随机数由调用者填充
+func (l layout) compose(t, machine, tenant, step int64) ID {
+	return ID(
+		int64(l.versionTag) |
+			(t-l.epoch)<<l.timeShift |
+			(tenant << l.tenantShift) |
+			(machine << l.machineShift) |
+			(step << l.stepShift),
+	)
+}
+
+func (l layout) time(id ID) int64 {
+	return (int64(id)>>l.timeShift)&l.timeMask + l.epoch
+}
+
+func (l layout) timestamp(id ID) time.Time {
+	return time.Unix(0, l.time(id)*int64(l.unit))
+}
+
+func (l layout) machine(id ID) int64 {
+	return int64(id) >> l.machineShift & l.machineMax
+}
+
+func (l layout) version(id ID) int64 {
+	if l.versionMax == 0 {
+		return 0
+	}
+	return int64(uint64(id) >> l.versionShift & l.versionMax)
+}
+
+func (l layout) tenant(id ID) int64 {
+	return int64(id) >> l.tenantShift & l.tenantMax
+}
+
+func (l layout) step(id ID) int64 {
+	return int64(id) >> l.stepShift & l.stepMask
+}
+
+func (l layout) entropy(id ID) int64 {
+	return int64(id) & l.entropyMask
+}
+
+func (l layout) region(id ID) int64 {
+	return l.machine(id) >> l.regionShift
+}
+
+func (l layout) datacenter(id ID) int64 {
+	return l.machine(id) >> l.workerBits & l.dcMax
+}
+
+func (l layout) worker(id ID) int64 {
+	return l.machine(id) & l.workerMask
+}
@@ -0,0 +1,55 @@
+package snowflake
+
+import "errors"
+
+// 机器节点ID的租约, 由 machineid.Lease 实现
+type MachineLease interface {
+	// 租约失效时关闭
+	Lost() <-chan struct{}
+
+	// 租约停止续约(正常释放或失效)之后关闭
+	Done() <-chan struct{}
+
+	// 租约失效的原因
+	Err() error
+}
+
+// 隐藏租约的 Close, 用于共用同一个租约的多个 Node
+type sharedLease struct {
+	MachineLease
+}
+
+// 租约失效之后生成ID返回的错误
+func (n *Node) leaseErr() error {
+	if err := n.lease.Err(); err != nil && errors.Is(err, ErrLeaseLost) {
+		return err
+	}
+	return ErrLeaseLost
+}
+
+// 等待租约失效并通知
+func (n *Node) watchLease(onLost func(err error)) {
+	select {
+	case <-n.lease.Lost():
+	case <-n.done:
+		return
+	case <-n.lease.Done():
+		// 正常释放时 Lost 与 Done 可能同时就绪
+		select {
+		case <-n.lease.Lost():
+		default:
+			return
+		}
+	}
+
+	// Shutdown 释放租约时 Lost 同样会被关闭, 不是失效
+	select {
+	case <-n.done:
+		return
+	default:
+	}
+
+	if onLost != nil {
+		onLost(n.lease.Err())
+	}
+}
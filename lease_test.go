@@ -0,0 +1,214 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 可以手动使之失效的租约
+type testLease struct {
+	lost chan struct{}
+	done chan struct{}
+	once sync.Once
+	err  error
+}
+
+func newTestLease() *testLease {
+	return &testLease{lost: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (l *testLease) Lost() <-chan struct{} { return l.lost }
+func (l *testLease) Done() <-chan struct{} { return l.done }
+
+func (l *testLease) Err() error {
+	select {
+	case <-l.lost:
+		return l.err
+	default:
+		return nil
+	}
+}
+
+func (l *testLease) fail(err error) {
+	l.once.Do(func() {
+		l.err = err
+		close(l.lost)
+		close(l.done)
+	})
+}
+
+// 租约失效之后所有并发生成的调用都返回 ErrLeaseLost, 回调只调用一次
+func TestNodeLeaseLost(t *testing.T) {
+	lease := newTestLease()
+	lost := make(chan error, 2)
+	node, err := NewNode(1, WithLease(lease, func(err error) { lost <- err }), WithFencing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	const workers = 8
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := node.GenerateErr(); err != nil && !errors.Is(err, ErrSequenceExhausted) {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	cause := fmt.Errorf("%w: renew failed", ErrLeaseLost)
+	lease.fail(cause)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("got %v, want ErrLeaseLost", err)
+		}
+	}
+
+	select {
+	case err := <-lost:
+		if err != cause {
+			t.Errorf("OnLeaseLost: got %v, want %v", err, cause)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnLeaseLost not called")
+	}
+	select {
+	case err := <-lost:
+		t.Errorf("OnLeaseLost called again with %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// 机器节点ID超出范围时不启动租约监听, 也不调用溢出预警
+func TestNewNodeMachineOutOfRange(t *testing.T) {
+	lease := newTestLease()
+	lost := make(chan error, 1)
+	warned := make(chan time.Duration, 1)
+	_, err := NewNode(1<<20,
+		WithLease(lease, func(err error) { lost <- err }),
+		WithOverflowWarning(time.Duration(1<<62), func(d time.Duration) { warned <- d }),
+	)
+	if !errors.Is(err, ErrMachineIDOutOfRange) {
+		t.Fatalf("got %v, want ErrMachineIDOutOfRange", err)
+	}
+
+	lease.fail(ErrLeaseLost)
+	select {
+	case err := <-lost:
+		t.Errorf("OnLeaseLost called with %v for a Node that was never returned", err)
+	case d := <-warned:
+		t.Errorf("OnOverflowWarning called with %s for a Node that was never returned", d)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// 与 machineid.Lease 一样在 Close 时关闭 Lost 的租约
+type closableLease struct {
+	*testLease
+	closes int32
+}
+
+func (l *closableLease) Close() error {
+	atomic.AddInt32(&l.closes, 1)
+	l.fail(ErrLeaseLost)
+	return nil
+}
+
+// Shutdown 释放租约时不调用 OnLeaseLost, 之后返回 ErrNodeClosed
+func TestNodeShutdownLease(t *testing.T) {
+	lease := &closableLease{testLease: newTestLease()}
+	lost := make(chan error, 1)
+	node, err := NewNode(1, WithLease(lease, func(err error) { lost <- err }), WithFencing())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&lease.closes); n != 1 {
+		t.Errorf("lease closed %d times, want 1", n)
+	}
+	if _, err := node.GenerateErr(); !errors.Is(err, ErrNodeClosed) {
+		t.Errorf("after Close: got %v, want ErrNodeClosed", err)
+	}
+	select {
+	case err := <-lost:
+		t.Errorf("OnLeaseLost called with %v after Close", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// NodePool 与 MultiNode 的每个 Node 使用不同的机器节点ID, 不能共用租约
+func TestSharedLease(t *testing.T) {
+	lease := newTestLease()
+	if _, err := NewNodePool(0, WithLease(lease, nil)); !errors.Is(err, ErrSharedLease) {
+		t.Errorf("NewNodePool: got %v, want ErrSharedLease", err)
+	}
+	if _, err := NewMultiNode(WithLease(lease, nil)); !errors.Is(err, ErrSharedLease) {
+		t.Errorf("NewMultiNode: got %v, want ErrSharedLease", err)
+	}
+}
+
+// ShardedNode 的子Node共用租约, 失效时只通知一次, Close 时只释放一次
+func TestShardedNodeLease(t *testing.T) {
+	lease := &closableLease{testLease: newTestLease()}
+	lost := make(chan error, 4)
+	s, err := NewShardedNode(1, 4, WithLease(lease, func(err error) { lost <- err }), WithFencing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GenerateErr(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&lease.closes); n != 1 {
+		t.Errorf("lease closed %d times, want 1", n)
+	}
+	select {
+	case err := <-lost:
+		t.Errorf("OnLeaseLost called with %v after Close", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	lease = &closableLease{testLease: newTestLease()}
+	s, err = NewShardedNode(1, 4, WithLease(lease, func(err error) { lost <- err }), WithFencing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	lease.fail(ErrLeaseLost)
+	if _, err := s.GenerateErr(); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("after lease lost: got %v, want ErrLeaseLost", err)
+	}
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("OnLeaseLost not called")
+	}
+	select {
+	case err := <-lost:
+		t.Errorf("OnLeaseLost called again with %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
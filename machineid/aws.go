@@ -0,0 +1,122 @@
+package machineid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const (
+	ec2Endpoint = "http://169.254.169.254"
+
+	// ECS 任务元数据服务 v4 的地址
+	ecsMetadataEnv = "ECS_CONTAINER_METADATA_URI_V4"
+)
+
+// 通过 EC2 实例元数据服务(IMDSv2)获取机器节点ID, 缺省使用实例ID的哈希值
+// 指定 WithIPSuffix 时使用私有IP地址的低位, 同一子网内不会重复
+func FromEC2(opts ...Option) Provider {
+	return FromEC2Context(opts...).Provider()
+}
+
+// 与 FromEC2 相同, 请求元数据服务时使用 ctx
+func FromEC2Context(opts ...Option) ContextProvider {
+	c := newConfig(opts)
+	if c.endpoint == "" {
+		c.endpoint = ec2Endpoint
+	}
+	return func(ctx context.Context) (int64, error) {
+		token, err := fetch(ctx, c, http.MethodPut, c.endpoint+"/latest/api/token",
+			map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+		if err != nil {
+			return -1, fmt.Errorf("EC2 metadata token: %w", err)
+		}
+		header := map[string]string{"X-aws-ec2-metadata-token": token}
+
+		if c.ipSuffix {
+			ip, err := fetch(ctx, c, http.MethodGet, c.endpoint+"/latest/meta-data/local-ipv4", header)
+			if err != nil {
+				return -1, err
+			}
+			return ipSuffix(ip, c)
+		}
+
+		id, err := fetch(ctx, c, http.MethodGet, c.endpoint+"/latest/meta-data/instance-id", header)
+		if err != nil {
+			return -1, err
+		}
+		return hashID(id, c), nil
+	}
+}
+
+// 通过 ECS 任务元数据服务 v4 获取机器节点ID, 使用任务 ARN 的哈希值
+// 不在 ECS 中运行(没有 ECS_CONTAINER_METADATA_URI_V4)时返回 ErrEnvNotSet
+// 指定 WithIPSuffix 时使用任务的私有IP地址(awsvpc 网络模式)的低位
+func FromECS(opts ...Option) Provider {
+	return FromECSContext(opts...).Provider()
+}
+
+// 与 FromECS 相同, 请求元数据服务时使用 ctx
+func FromECSContext(opts ...Option) ContextProvider {
+	c := newConfig(opts)
+	return func(ctx context.Context) (int64, error) {
+		endpoint := c.endpoint
+		if endpoint == "" {
+			endpoint = os.Getenv(ecsMetadataEnv)
+		}
+		if endpoint == "" {
+			return -1, fmt.Errorf("%w: %s", ErrEnvNotSet, ecsMetadataEnv)
+		}
+
+		body, err := fetch(ctx, c, http.MethodGet, endpoint+"/task", nil)
+		if err != nil {
+			return -1, err
+		}
+
+		var task struct {
+			TaskARN    string
+			Containers []struct {
+				Networks []struct {
+					IPv4Addresses []string
+				}
+			}
+		}
+		if err := json.Unmarshal([]byte(body), &task); err != nil {
+			return -1, fmt.Errorf("ECS task metadata: %w", err)
+		}
+
+		if c.ipSuffix {
+			for _, ctr := range task.Containers {
+				for _, n := range ctr.Networks {
+					if len(n.IPv4Addresses) > 0 {
+						return ipSuffix(n.IPv4Addresses[0], c)
+					}
+				}
+			}
+			return -1, fmt.Errorf("ECS task metadata has no IPv4 address")
+		}
+
+		if task.TaskARN == "" {
+			return -1, fmt.Errorf("ECS task metadata has no TaskARN")
+		}
+		return hashID(task.TaskARN, c), nil
+	}
+}
+
+// 在 ECS 中运行时使用 FromECS, 否则使用 FromEC2
+func FromAWS(opts ...Option) Provider {
+	return FromAWSContext(opts...).Provider()
+}
+
+// 与 FromAWS 相同, 请求元数据服务时使用 ctx
+func FromAWSContext(opts ...Option) ContextProvider {
+	ecs, ec2 := FromECSContext(opts...), FromEC2Context(opts...)
+	return func(ctx context.Context) (int64, error) {
+		if os.Getenv(ecsMetadataEnv) != "" {
+			return ecs(ctx)
+		}
+		return ec2(ctx)
+	}
+}
@@ -0,0 +1,64 @@
+package machineid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ming913/snowflake"
+)
+
+const azureEndpoint = "http://169.254.169.254"
+
+// 通过 Azure 实例元数据服务(IMDS)获取机器节点ID, 适用于 VM, VMSS 与 AKS
+// 属于规模集(VMSS)时使用实例名称末尾 "_" 之后的序号, 序号超出位数范围时返回错误
+// 否则使用资源ID的哈希值
+func FromAzureIMDS(opts ...Option) Provider {
+	return FromAzureIMDSContext(opts...).Provider()
+}
+
+// 与 FromAzureIMDS 相同, 请求元数据服务时使用 ctx
+func FromAzureIMDSContext(opts ...Option) ContextProvider {
+	c := newConfig(opts)
+	if c.endpoint == "" {
+		c.endpoint = azureEndpoint
+	}
+	header := map[string]string{"Metadata": "true"}
+
+	return func(ctx context.Context) (int64, error) {
+		body, err := fetch(ctx, c, http.MethodGet,
+			c.endpoint+"/metadata/instance/compute?api-version=2021-02-01", header)
+		if err != nil {
+			return -1, err
+		}
+
+		var compute struct {
+			Name           string `json:"name"`
+			ResourceID     string `json:"resourceId"`
+			VMScaleSetName string `json:"vmScaleSetName"`
+		}
+		if err := json.Unmarshal([]byte(body), &compute); err != nil {
+			return -1, fmt.Errorf("Azure IMDS: %w", err)
+		}
+
+		if compute.VMScaleSetName != "" {
+			if i := strings.LastIndexByte(compute.Name, '_'); i >= 0 {
+				if n, err := strconv.ParseInt(compute.Name[i+1:], 10, 64); err == nil && n >= 0 {
+					if n > c.mask() {
+						return -1, fmt.Errorf("%w: ordinal %d of VMSS instance %q must be between 0 and %d",
+							snowflake.ErrMachineIDOutOfRange, n, compute.Name, c.mask())
+					}
+					return n, nil
+				}
+			}
+		}
+
+		if compute.ResourceID == "" {
+			return -1, fmt.Errorf("Azure IMDS returned no resourceId")
+		}
+		return hashID(compute.ResourceID, c), nil
+	}
+}
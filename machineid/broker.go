@@ -0,0 +1,250 @@
+package machineid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ming913/snowflake"
+)
+
+// 从 brokerserver 租用机器节点ID, endpoint 为服务地址, 例如 "http://broker:8080"
+// 使用 WithCache 时租约缓存在本地文件中, 进程重启之后优先续约缓存的租约以保持相同的ID,
+// broker 暂时不可用时继续使用未到期的缓存租约, 后台持续重试续约直到租约到期
+// 租约的有效期由 broker 决定, 使用完毕之后请调用 Lease.Close 释放
+func NewBrokerLease(ctx context.Context, endpoint string, opts ...Option) (*Lease, error) {
+	c := newConfig(opts)
+	if c.owner == "" {
+		c.owner, _ = os.Hostname()
+	}
+	s := &brokerStore{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		timeout:  c.timeout,
+		cache:    c.cache,
+		owner:    c.owner,
+	}
+
+	if g, ok := s.load(); ok && g.ID <= c.mask() {
+		s.grant = g
+		err := s.renew(ctx, g.ID)
+		// 缓存中的到期时间按本机时钟记录, broker 不可用时只使用还有剩余时间的租约
+		if err == nil || !errors.Is(err, ErrLeaseLost) && time.Until(g.Expires) > 0 {
+			c.ttl = g.TTL
+			return newLease(s, g.ID, g.Expires.Add(-g.TTL), c), nil
+		}
+		if !errors.Is(err, ErrLeaseLost) {
+			return nil, err
+		}
+	}
+
+	g, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if g.TTL <= 0 {
+		return nil, fmt.Errorf("broker granted an expired lease for %d", g.ID)
+	}
+	if g.ID > c.mask() {
+		s.release(ctx, g.ID)
+		return nil, fmt.Errorf("%w: broker granted %d, must be between 0 and %d", snowflake.ErrMachineIDOutOfRange, g.ID, c.mask())
+	}
+	c.ttl = g.TTL
+	return newLease(s, g.ID, g.Expires.Add(-g.TTL), c), nil
+}
+
+// broker 发放的租约, TTL 为 broker 返回的剩余有效期
+// Expires 由客户端按发送请求时的本机时间加上 TTL 计算, 与 broker 的时钟偏差无关, 一起写入缓存
+type brokerGrant struct {
+	ID      int64         `json:"id"`
+	Token   string        `json:"token"`
+	Owner   string        `json:"owner,omitempty"`
+	Expires time.Time     `json:"expires"`
+	TTL     time.Duration `json:"ttl,omitempty"`
+}
+
+type brokerStore struct {
+	endpoint string
+	timeout  time.Duration
+	cache    string
+	owner    string
+
+	mu    sync.Mutex
+	grant brokerGrant
+}
+
+func (s *brokerStore) acquire(ctx context.Context) (brokerGrant, error) {
+	var g brokerGrant
+	sent := time.Now()
+	if err := s.do(ctx, http.MethodPost, "", map[string]string{"owner": s.owner}, &g); err != nil {
+		return brokerGrant{}, err
+	}
+	return s.update(g, sent), nil
+}
+
+func (s *brokerStore) renew(ctx context.Context, id int64) error {
+	var g brokerGrant
+	sent := time.Now()
+	err := s.do(ctx, http.MethodPut, "/"+strconv.FormatInt(id, 10), map[string]string{"token": s.token()}, &g)
+	if errors.Is(err, ErrLeaseLost) {
+		s.remove()
+	}
+	if err != nil {
+		return err
+	}
+	s.update(g, sent)
+	return nil
+}
+
+func (s *brokerStore) release(ctx context.Context, id int64) error {
+	err := s.do(ctx, http.MethodDelete, "/"+strconv.FormatInt(id, 10), map[string]string{"token": s.token()}, nil)
+	if err == nil {
+		s.remove()
+	}
+	return err
+}
+
+func (s *brokerStore) expires() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.grant.Expires
+}
+
+func (s *brokerStore) token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.grant.Token
+}
+
+// 记录 broker 返回的租约并写入缓存, 写入失败时只影响重启之后的恢复
+// 到期时间从发送请求之前的时间 sent 开始计算, 不会晚于 broker 记录的到期时间
+func (s *brokerStore) update(g brokerGrant, sent time.Time) brokerGrant {
+	// 不返回 ttl 的旧版本 broker 只能按照 broker 的时钟计算
+	if g.TTL <= 0 {
+		g.TTL = g.Expires.Sub(sent)
+	}
+	g.Expires = sent.Add(g.TTL)
+	s.mu.Lock()
+	s.grant = g
+	s.mu.Unlock()
+
+	if s.cache != "" {
+		writeGrantFile(s.cache, g)
+	}
+	return g
+}
+
+// 读取缓存的租约
+func (s *brokerStore) load() (brokerGrant, bool) {
+	if s.cache == "" {
+		return brokerGrant{}, false
+	}
+	b, err := os.ReadFile(s.cache)
+	if err != nil {
+		return brokerGrant{}, false
+	}
+	var g brokerGrant
+	if err := json.Unmarshal(b, &g); err != nil || g.Token == "" || g.ID < 0 || g.TTL <= 0 {
+		return brokerGrant{}, false
+	}
+	return g, true
+}
+
+func (s *brokerStore) remove() {
+	if s.cache != "" {
+		os.Remove(s.cache)
+	}
+}
+
+// 请求 broker 的接口, 409 返回 ErrLeaseLost, 503 返回 ErrNoFreeID
+func (s *brokerStore) do(ctx context.Context, method, path string, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := s.endpoint + "/v1/leases" + path
+	req, err := http.NewRequest(method, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(data, out)
+	case http.StatusNoContent:
+		return nil
+	case http.StatusConflict:
+		return ErrLeaseLost
+	case http.StatusServiceUnavailable:
+		return ErrNoFreeID
+	}
+
+	var e struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(data, &e)
+	if e.Error == "" {
+		e.Error = resp.Status
+	}
+	return fmt.Errorf("broker %s %s: %s", method, url, e.Error)
+}
+
+// 原子地写入缓存文件, 文件中包含令牌, 权限为 0600
+func writeGrantFile(path string, g brokerGrant) error {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,150 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ming913/snowflake/brokerserver"
+)
+
+func newBroker(t *testing.T, ttl time.Duration) (*brokerserver.Server, *httptest.Server) {
+	t.Helper()
+	s, err := brokerserver.New(brokerserver.NewMemoryStore(), brokerserver.WithBits(4), brokerserver.WithTTL(ttl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// 到期时间按本机时钟与 broker 返回的剩余有效期计算, 不受 broker 时钟偏差影响
+func TestBrokerLeaseClockSkew(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// broker 的时钟慢了一天
+		w.Write([]byte(`{"id": 3, "token": "t", "expires": "2000-01-01T00:00:00Z", "ttl": 60000000000}`))
+	}))
+	defer srv.Close()
+
+	before := time.Now()
+	l, err := NewBrokerLease(context.Background(), srv.URL, WithBits(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+	defer l.Close()
+
+	if e := l.Expires(); e.Before(before.Add(time.Minute)) || e.After(after.Add(time.Minute)) {
+		t.Errorf("Expires: got %s, want a minute after the request", e)
+	}
+}
+
+// 重启之后续约缓存的租约, 保持相同的ID
+func TestBrokerLeaseCache(t *testing.T) {
+	s, srv := newBroker(t, time.Minute)
+	cache := filepath.Join(t.TempDir(), "lease.json")
+
+	first, err := NewBrokerLease(context.Background(), srv.URL, WithBits(4), WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	l, err := NewBrokerLease(context.Background(), srv.URL, WithBits(4), WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.ID() != first.ID() {
+		t.Errorf("got ID %d, want the cached ID %d", l.ID(), first.ID())
+	}
+	if n := len(s.Grants()); n != 1 {
+		t.Errorf("Grants: got %d, want 1", n)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Errorf("cache after Close: got %v, want it removed", err)
+	}
+}
+
+// broker 不可用时使用未到期的缓存租约, 到期的缓存租约返回错误
+func TestBrokerLeaseBrokerDown(t *testing.T) {
+	_, srv := newBroker(t, time.Minute)
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "lease.json")
+
+	first, err := NewBrokerLease(context.Background(), srv.URL, WithBits(4), WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	srv.Close()
+
+	l, err := NewBrokerLease(context.Background(), srv.URL, WithBits(4), WithCache(cache))
+	if err != nil {
+		t.Fatalf("broker down with a valid cached lease: %v", err)
+	}
+	if l.ID() != first.ID() {
+		t.Errorf("got ID %d, want the cached ID %d", l.ID(), first.ID())
+	}
+	l.Close()
+
+	expired := filepath.Join(dir, "expired.json")
+	if err := writeGrantFile(expired, brokerGrant{ID: 1, Token: "t", Expires: time.Now().Add(-time.Second), TTL: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBrokerLease(context.Background(), srv.URL, WithBits(4), WithCache(expired)); err == nil {
+		t.Error("broker down with an expired cached lease: got a lease, want an error")
+	}
+}
+
+// 缓存的租约已被释放时重新申请; 续约返回 409 时租约失效
+func TestBrokerLeaseConflict(t *testing.T) {
+	s, srv := newBroker(t, 300*time.Millisecond)
+	cache := filepath.Join(t.TempDir(), "lease.json")
+
+	if err := writeGrantFile(cache, brokerGrant{ID: 1, Token: "stale", Expires: time.Now().Add(time.Minute), TTL: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	var rec lostRecorder
+	l, err := NewBrokerLease(context.Background(), srv.URL, WithBits(4), WithCache(cache), rec.option())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if n := len(s.Grants()); n != 1 || s.Grants()[0].ID != l.ID() {
+		t.Fatalf("Grants: got %+v, want only %d", s.Grants(), l.ID())
+	}
+
+	// 被释放之后续约返回 409
+	if err := s.Release(context.Background(), l.ID(), grantToken(t, cache)); err != nil {
+		t.Fatal(err)
+	}
+	waitLost(t, l, 2*time.Second)
+	if id, err := rec.get(); id != l.ID() || !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("onLost: got (%d, %v), want (%d, ErrLeaseLost)", id, err, l.ID())
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Errorf("cache after 409: got %v, want it removed", err)
+	}
+}
+
+// 读取缓存文件中的令牌
+func grantToken(t *testing.T, path string) string {
+	t.Helper()
+	s := &brokerStore{cache: path}
+	g, ok := s.load()
+	if !ok {
+		t.Fatalf("no cached lease in %s", path)
+	}
+	return g.Token
+}
@@ -0,0 +1,86 @@
+package machineid
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 按顺序尝试的多个 Provider, 记录最后一次使用的是哪一个
+type ChainProvider struct {
+	providers []Provider
+
+	mu     sync.Mutex
+	winner int
+	errs   []error
+}
+
+// 返回按顺序尝试 providers 的 ChainProvider, 例如:
+//
+//	chain := machineid.Chain(
+//		machineid.FromEnv("SNOWFLAKE_MACHINE_ID"),
+//		machineid.FromKubernetes(),
+//		machineid.FromPrivateIP(),
+//		machineid.FromRandom(),
+//	)
+//	id, err := chain.MachineID()
+//	if chain.Winner() == 3 {
+//		log.Printf("using random machine ID %d: %v", id, chain.Errors())
+//	}
+func Chain(providers ...Provider) *ChainProvider {
+	return &ChainProvider{providers: providers, winner: -1}
+}
+
+// 依次尝试每个 Provider, 返回第一个成功的结果, 全部失败时返回 ErrAllProvidersFailed
+func (c *ChainProvider) MachineID() (int64, error) {
+	var errs []error
+	for i, p := range c.providers {
+		id, err := p()
+		if err == nil {
+			c.record(i, errs)
+			return id, nil
+		}
+		errs = append(errs, err)
+	}
+	c.record(-1, errs)
+
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return -1, fmt.Errorf("%w: %s", ErrAllProvidersFailed, strings.Join(msgs, "; "))
+}
+
+// 作为 Provider 使用
+func (c *ChainProvider) Provider() Provider {
+	return c.MachineID
+}
+
+// 上次成功的 Provider 的序号, 尚未调用或全部失败时为 -1
+func (c *ChainProvider) Winner() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.winner
+}
+
+// 上次调用时在成功之前失败的 Provider 返回的错误
+func (c *ChainProvider) Errors() []error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]error(nil), c.errs...)
+}
+
+func (c *ChainProvider) record(winner int, errs []error) {
+	c.mu.Lock()
+	c.winner, c.errs = winner, errs
+	c.mu.Unlock()
+}
+
+// 随机分配机器节点ID, 总是成功, 一般作为 Chain 的最后一项用于本地开发
+// 多个进程之间可能重复, 不应在生产环境单独使用
+func FromRandom(opts ...Option) Provider {
+	c := newConfig(opts)
+	return func() (int64, error) {
+		return randomInt63() & c.mask(), nil
+	}
+}
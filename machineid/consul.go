@@ -0,0 +1,70 @@
+package machineid
+
+import (
+	"context"
+	"time"
+)
+
+// Lease 使用的 Consul 操作, 可以通过适配器使用 github.com/hashicorp/consul/api, 例如:
+//
+//	func (a adapter) CreateSession(ctx context.Context, name string, ttl time.Duration, checks []string) (string, error) {
+//		id, _, err := a.c.Session().Create(&api.SessionEntry{
+//			Name: name, TTL: ttl.String(), Behavior: api.SessionBehaviorDelete, Checks: checks,
+//		}, (&api.WriteOptions{}).WithContext(ctx))
+//		return id, err
+//	}
+//
+// RenewSession 在会话不存在时应返回 ErrLeaseLost
+type ConsulClient interface {
+	CreateSession(ctx context.Context, name string, ttl time.Duration, checks []string) (string, error)
+	RenewSession(ctx context.Context, session string) error
+	DestroySession(ctx context.Context, session string) error
+	Acquire(ctx context.Context, key string, value []byte, session string) (bool, error)
+	Release(ctx context.Context, key string, session string) (bool, error)
+}
+
+// 通过 Consul 会话与 KV 锁占用一个空闲的机器节点ID
+// key 为 WithPrefix 指定的前缀, WithDatacenter 指定的数据中心与ID, 会话有效期由 WithTTL 指定
+// 会话关联 WithHealthChecks 指定的健康检查, 节点不健康或会话过期时 Consul 自动删除 key
+func NewConsulLease(ctx context.Context, client ConsulClient, opts ...Option) (*Lease, error) {
+	c := newConfig(opts)
+	if c.datacenter != "" {
+		c.prefix += c.datacenter + "/"
+	}
+
+	session, err := client.CreateSession(ctx, "snowflake-machine-id", c.ttl, c.checks)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &consulStore{client: client, prefix: c.prefix, session: session, token: newToken()}
+	l, err := acquire(ctx, s, c)
+	if err != nil {
+		client.DestroySession(ctx, session)
+		return nil, err
+	}
+	return l, nil
+}
+
+type consulStore struct {
+	client  ConsulClient
+	prefix  string
+	session string
+	token   string
+}
+
+func (s *consulStore) claim(ctx context.Context, id int64) (bool, error) {
+	return s.client.Acquire(ctx, leaseKey(s.prefix, id), []byte(s.token), s.session)
+}
+
+func (s *consulStore) renew(ctx context.Context, id int64) error {
+	return s.client.RenewSession(ctx, s.session)
+}
+
+// 释放锁之后销毁会话, 会话使用 delete 行为时同时删除 key
+func (s *consulStore) release(ctx context.Context, id int64) error {
+	if _, err := s.client.Release(ctx, leaseKey(s.prefix, id), s.session); err != nil {
+		return err
+	}
+	return s.client.DestroySession(ctx, s.session)
+}
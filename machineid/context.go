@@ -0,0 +1,82 @@
+package machineid
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// 可以取消与设置超时的 Provider, 适用于需要访问网络的实现
+type ContextProvider func(ctx context.Context) (int64, error)
+
+// 转换为 ContextProvider, p 在新的goroutine中执行, ctx 结束时不再等待并返回 ctx.Err()
+// p 本身无法被取消, 返回之后goroutine仍然运行到 p 结束; 访问元数据服务时请使用 FromEC2Context 等原生实现
+func (p Provider) Context() ContextProvider {
+	return func(ctx context.Context) (int64, error) {
+		if err := ctx.Err(); err != nil {
+			return -1, err
+		}
+
+		type result struct {
+			id  int64
+			err error
+		}
+		ch := make(chan result, 1)
+		go func() {
+			id, err := p()
+			ch <- result{id, err}
+		}()
+
+		select {
+		case r := <-ch:
+			return r.id, r.err
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		}
+	}
+}
+
+// 转换为使用 context.Background 的 Provider
+func (p ContextProvider) Provider() Provider {
+	return func() (int64, error) {
+		return p(context.Background())
+	}
+}
+
+// 失败时按照指数退避重试 p, 次数与间隔由 WithAttempts 与 WithBackoff 指定
+// ctx 结束时停止重试并返回 ctx.Err(), 次数小于1时只尝试1次
+func Retry(p ContextProvider, opts ...Option) ContextProvider {
+	c := newConfig(opts)
+	if c.attempts < 1 {
+		c.attempts = 1
+	}
+	return func(ctx context.Context) (int64, error) {
+		backoff := c.backoff
+
+		var err error
+		for i := 0; i < c.attempts; i++ {
+			if i > 0 {
+				t := time.NewTimer(jitter(backoff))
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					return -1, ctx.Err()
+				}
+
+				if backoff *= 2; backoff > c.maxBackoff {
+					backoff = c.maxBackoff
+				}
+			}
+
+			var id int64
+			if id, err = p(ctx); err == nil {
+				return id, nil
+			}
+			if ctx.Err() != nil {
+				return -1, ctx.Err()
+			}
+		}
+		return -1, fmt.Errorf("after %d attempts: %w", c.attempts, err)
+	}
+}
@@ -0,0 +1,77 @@
+package machineid
+
+import (
+	"context"
+	"time"
+)
+
+// Lease 使用的 DynamoDB 条件写入, 可以通过适配器使用 aws-sdk-go-v2, 表的分区键为数值类型的 machine_id
+// expires_at 为 Unix 秒, 可以同时设置为表的 TTL 属性, 由 DynamoDB 自动删除过期的租约. 例如 PutIfAvailable:
+//
+//	_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+//		TableName: &table,
+//		Item: map[string]types.AttributeValue{
+//			"machine_id": &types.AttributeValueMemberN{Value: strconv.FormatInt(id, 10)},
+//			"owner":      &types.AttributeValueMemberS{Value: owner},
+//			"expires_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(expires, 10)},
+//		},
+//		ConditionExpression: aws.String("attribute_not_exists(machine_id) OR expires_at < :now"),
+//		ExpressionAttributeValues: map[string]types.AttributeValue{
+//			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+//		},
+//	})
+//	var ccf *types.ConditionalCheckFailedException
+//	if errors.As(err, &ccf) {
+//		return false, nil
+//	}
+//	return err == nil, err
+//
+// Renew 使用条件 "owner = :owner" 更新 expires_at, Delete 使用同样的条件删除
+type DynamoDBClient interface {
+	PutIfAvailable(ctx context.Context, table string, id int64, owner string, expires, now int64) (bool, error)
+	Renew(ctx context.Context, table string, id int64, owner string, expires int64) (bool, error)
+	Delete(ctx context.Context, table string, id int64, owner string) error
+}
+
+// 通过 DynamoDB 条件写入租用空闲或已过期的机器节点ID, 表名由 WithTable 指定, 有效期由 WithTTL 指定
+// 后台定期续约, 使用完毕之后请调用 Lease.Close 释放
+func NewDynamoDBLease(ctx context.Context, client DynamoDBClient, opts ...Option) (*Lease, error) {
+	c := newConfig(opts)
+	return acquire(ctx, &dynamoStore{
+		client: client,
+		table:  c.table,
+		ttl:    c.ttl,
+		owner:  newToken(),
+	}, c)
+}
+
+type dynamoStore struct {
+	client DynamoDBClient
+	table  string
+	ttl    time.Duration
+	owner  string
+}
+
+// 过期时间向上取整到秒
+func (s *dynamoStore) expires() int64 {
+	return time.Now().Add(s.ttl + time.Second - 1).Unix()
+}
+
+func (s *dynamoStore) claim(ctx context.Context, id int64) (bool, error) {
+	return s.client.PutIfAvailable(ctx, s.table, id, s.owner, s.expires(), time.Now().Unix())
+}
+
+func (s *dynamoStore) renew(ctx context.Context, id int64) error {
+	ok, err := s.client.Renew(ctx, s.table, id, s.owner, s.expires())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *dynamoStore) release(ctx context.Context, id int64) error {
+	return s.client.Delete(ctx, s.table, id, s.owner)
+}
@@ -0,0 +1,32 @@
+package machineid
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ming913/snowflake"
+)
+
+// 从环境变量 name 读取机器节点ID, 例如 FromEnv("SNOWFLAKE_MACHINE_ID")
+// 未设置时返回 ErrEnvNotSet, 超出 WithBits 指定的位数范围时返回 snowflake.ErrMachineIDOutOfRange
+func FromEnv(name string, opts ...Option) Provider {
+	c := newConfig(opts)
+	return func() (int64, error) {
+		v, ok := os.LookupEnv(name)
+		v = strings.TrimSpace(v)
+		if !ok || v == "" {
+			return -1, fmt.Errorf("%w: %s", ErrEnvNotSet, name)
+		}
+
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return -1, fmt.Errorf("%s=%q is not a valid machine ID: %w", name, v, err)
+		}
+		if id < 0 || id > c.mask() {
+			return -1, fmt.Errorf("%w: %s=%d must be between 0 and %d", snowflake.ErrMachineIDOutOfRange, name, id, c.mask())
+		}
+		return id, nil
+	}
+}
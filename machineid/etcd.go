@@ -0,0 +1,76 @@
+package machineid
+
+import (
+	"context"
+	"time"
+)
+
+// Lease 使用的 etcd 操作, 可以通过适配器使用 go.etcd.io/etcd/client/v3, 例如 PutIfAbsent:
+//
+//	resp, err := cli.Txn(ctx).
+//		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+//		Then(clientv3.OpPut(key, value, clientv3.WithLease(clientv3.LeaseID(lease)))).
+//		Commit()
+//	return resp.Succeeded, err
+//
+// KeepAliveOnce 在租约不存在时应返回 ErrLeaseLost, WatchDelete 在 key 被删除(包括租约到期或被撤销)时关闭通道
+type EtcdClient interface {
+	Grant(ctx context.Context, ttl int64) (lease int64, err error)
+	PutIfAbsent(ctx context.Context, key, value string, lease int64) (bool, error)
+	KeepAliveOnce(ctx context.Context, lease int64) error
+	Revoke(ctx context.Context, lease int64) error
+	WatchDelete(ctx context.Context, key string) <-chan struct{}
+}
+
+// 通过 etcd 租约与事务占用一个空闲的机器节点ID, key 为 WithPrefix 指定的前缀加上ID, 绑定有效期为 WithTTL 的租约
+// 后台定期续约并监听 key 的删除, 失效时关闭 Lease.Lost 并调用 WithOnLost 指定的函数
+func NewEtcdLease(ctx context.Context, client EtcdClient, opts ...Option) (*Lease, error) {
+	c := newConfig(opts)
+
+	ttl := int64((c.ttl + time.Second - 1) / time.Second)
+	lease, err := client.Grant(ctx, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &etcdStore{client: client, prefix: c.prefix, lease: lease, token: newToken()}
+	l, err := acquire(ctx, s, c)
+	if err != nil {
+		client.Revoke(ctx, lease)
+		return nil, err
+	}
+	return l, nil
+}
+
+type etcdStore struct {
+	client EtcdClient
+	prefix string
+	lease  int64
+	token  string
+}
+
+func (s *etcdStore) claim(ctx context.Context, id int64) (bool, error) {
+	return s.client.PutIfAbsent(ctx, leaseKey(s.prefix, id), s.token, s.lease)
+}
+
+func (s *etcdStore) renew(ctx context.Context, id int64) error {
+	return s.client.KeepAliveOnce(ctx, s.lease)
+}
+
+// 撤销租约同时删除 key
+func (s *etcdStore) release(ctx context.Context, id int64) error {
+	return s.client.Revoke(ctx, s.lease)
+}
+
+func (s *etcdStore) watch(ctx context.Context, id int64) <-chan error {
+	ch := make(chan error, 1)
+	deleted := s.client.WatchDelete(ctx, leaseKey(s.prefix, id))
+	go func() {
+		select {
+		case <-deleted:
+			ch <- ErrLeaseLost
+		case <-ctx.Done():
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,89 @@
+package machineid
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ming913/snowflake"
+)
+
+// 从本地文件读取机器节点ID, 使主机重启之后保持相同的ID
+// 文件不存在时通过 WithDelegate 指定的 Provider 分配(缺省随机分配)并原子地写入文件,
+// 多个进程同时第一次启动时只有一个写入成功, 其余进程读取已写入的ID
+func FromFile(path string, opts ...Option) Provider {
+	c := newConfig(opts)
+	return func() (int64, error) {
+		id, err := readIDFile(path, c)
+		if err == nil || !os.IsNotExist(err) {
+			return id, err
+		}
+
+		if c.delegate != nil {
+			id, err = c.delegate()
+			if err != nil {
+				return -1, err
+			}
+		} else {
+			id = randomInt63() & c.mask()
+		}
+		if id < 0 || id > c.mask() {
+			return -1, fmt.Errorf("%w: allocated ID %d must be between 0 and %d", snowflake.ErrMachineIDOutOfRange, id, c.mask())
+		}
+
+		if err := writeIDFile(path, id); err != nil {
+			if os.IsExist(err) {
+				return readIDFile(path, c)
+			}
+			return -1, err
+		}
+		return id, nil
+	}
+}
+
+func readIDFile(path string, c config) (int64, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("%s is not a valid machine ID file: %w", path, err)
+	}
+	if id < 0 || id > c.mask() {
+		return -1, fmt.Errorf("%w: %s contains %d, must be between 0 and %d", snowflake.ErrMachineIDOutOfRange, path, id, c.mask())
+	}
+	return id, nil
+}
+
+// 写入临时文件之后硬链接到 path, path 已存在时返回 os.ErrExist
+func writeIDFile(path string, id int64) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.WriteString(strconv.FormatInt(id, 10) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Link(tmp, path)
+}
@@ -0,0 +1,41 @@
+package machineid
+
+import (
+	"context"
+	"net/http"
+)
+
+const gcpEndpoint = "http://metadata.google.internal"
+
+// 通过 GCE 元数据服务获取机器节点ID, 使用实例ID的哈希值, 适用于 GCE, GKE 与托管实例组(MIG)
+// 指定 WithIPSuffix 时使用第一个网卡的私有IP地址的低位
+func FromGCPMetadata(opts ...Option) Provider {
+	return FromGCPMetadataContext(opts...).Provider()
+}
+
+// 与 FromGCPMetadata 相同, 请求元数据服务时使用 ctx
+func FromGCPMetadataContext(opts ...Option) ContextProvider {
+	c := newConfig(opts)
+	if c.endpoint == "" {
+		c.endpoint = gcpEndpoint
+	}
+	header := map[string]string{"Metadata-Flavor": "Google"}
+
+	return func(ctx context.Context) (int64, error) {
+		base := c.endpoint + "/computeMetadata/v1/instance/"
+
+		if c.ipSuffix {
+			ip, err := fetch(ctx, c, http.MethodGet, base+"network-interfaces/0/ip", header)
+			if err != nil {
+				return -1, err
+			}
+			return ipSuffix(ip, c)
+		}
+
+		id, err := fetch(ctx, c, http.MethodGet, base+"id", header)
+		if err != nil {
+			return -1, err
+		}
+		return hashID(id, c), nil
+	}
+}
@@ -0,0 +1,50 @@
+package machineid
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ming913/snowflake"
+)
+
+// 使用主机名的 FNV-1a 哈希值作为机器节点ID, 位数由 WithBits 指定
+// 指定 WithOrdinal 时优先使用主机名末尾 "-" 之后的序号, 序号超出位数范围时返回错误
+func FromHostname(opts ...Option) Provider {
+	c := newConfig(opts)
+	return func() (int64, error) {
+		name, err := os.Hostname()
+		if err != nil {
+			return -1, err
+		}
+
+		if c.ordinal {
+			if n, ok := ordinal(name); ok {
+				if n > c.mask() {
+					return -1, fmt.Errorf("%w: ordinal %d of hostname %q must be between 0 and %d",
+						snowflake.ErrMachineIDOutOfRange, n, name, c.mask())
+				}
+				return n, nil
+			}
+		}
+
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		return int64(h.Sum64()) & c.mask(), nil
+	}
+}
+
+// 解析主机名末尾 "-" 之后的序号
+func ordinal(name string) (int64, bool) {
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 || i == len(name)-1 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(name[i+1:], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,65 @@
+package machineid
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// 使用第一个私有IPv4地址的低位作为机器节点ID, 位数由 WithBits 指定
+// 跳过未启用的网卡以及回环与链路本地地址, 网卡按照系统中的顺序选择
+// 例如 WithBits(16) 时使用最后两段地址, 与 Sonyflake 相同
+func FromPrivateIP(opts ...Option) Provider {
+	c := newConfig(opts)
+	return func() (int64, error) {
+		ip, err := privateIPv4()
+		if err != nil {
+			return -1, err
+		}
+		return int64(binary.BigEndian.Uint32(ip)) & c.mask(), nil
+	}
+}
+
+func privateIPv4() (net.IP, error) {
+	ips, err := localIPv4s()
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if ip.IsPrivate() {
+			return ip, nil
+		}
+	}
+	return nil, ErrNoPrivateIP
+}
+
+// 已启用网卡上的IPv4地址, 不包括回环与链路本地地址
+func localIPv4s() ([]net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipnet.IP.To4()
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			ips = append(ips, ip)
+		}
+	}
+	return ips, nil
+}
@@ -0,0 +1,71 @@
+package machineid
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/ming913/snowflake"
+)
+
+// 在 Kubernetes 中获取稳定的机器节点ID
+// 优先使用 Pod 名称末尾的 StatefulSet 序号, Pod 名称来自 Downward API 注入的环境变量 POD_NAME, 未设置时使用主机名
+// 没有序号且指定了 WithCIDR 时使用 POD_IP 在网段中的偏移
+// 不在集群中(没有 KUBERNETES_SERVICE_HOST)时返回 ErrNotInKubernetes
+func FromKubernetes(opts ...Option) Provider {
+	c := newConfig(opts)
+	return func() (int64, error) {
+		if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+			return -1, ErrNotInKubernetes
+		}
+
+		name := os.Getenv("POD_NAME")
+		if name == "" {
+			var err error
+			if name, err = os.Hostname(); err != nil {
+				return -1, err
+			}
+		}
+
+		if n, ok := ordinal(name); ok {
+			if n > c.mask() {
+				return -1, fmt.Errorf("%w: ordinal %d of pod %q must be between 0 and %d",
+					snowflake.ErrMachineIDOutOfRange, n, name, c.mask())
+			}
+			return n, nil
+		}
+
+		if c.cidr == "" {
+			return -1, fmt.Errorf("pod %q has no StatefulSet ordinal, set POD_NAME or use WithCIDR", name)
+		}
+
+		ip := net.ParseIP(os.Getenv("POD_IP"))
+		if ip == nil {
+			return -1, fmt.Errorf("%w: POD_IP is not set or invalid, expose status.podIP through the Downward API", ErrEnvNotSet)
+		}
+		return cidrOffset(ip, c)
+	}
+}
+
+// IP地址在 c.cidr 网段中的偏移
+func cidrOffset(ip net.IP, c config) (int64, error) {
+	_, network, err := net.ParseCIDR(c.cidr)
+	if err != nil {
+		return -1, err
+	}
+	ip4, base := ip.To4(), network.IP.To4()
+	if ip4 == nil || base == nil {
+		return -1, fmt.Errorf("only IPv4 CIDR is supported: %s", c.cidr)
+	}
+	if !network.Contains(ip4) {
+		return -1, fmt.Errorf("%w: %s is not in %s", snowflake.ErrMachineIDOutOfRange, ip, c.cidr)
+	}
+
+	off := int64(binary.BigEndian.Uint32(ip4) - binary.BigEndian.Uint32(base))
+	if off > c.mask() {
+		return -1, fmt.Errorf("%w: offset %d of %s in %s must be between 0 and %d",
+			snowflake.ErrMachineIDOutOfRange, off, ip, c.cidr, c.mask())
+	}
+	return off, nil
+}
@@ -0,0 +1,317 @@
+package machineid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 租约的存储, 例如 Redis 与 etcd
+type leaseStore interface {
+	// 续约, 不再持有时返回 ErrLeaseLost
+	renew(ctx context.Context, id int64) error
+
+	// 释放, 不再持有时忽略
+	release(ctx context.Context, id int64) error
+}
+
+// 可以按ID逐个尝试占用的存储
+type claimStore interface {
+	leaseStore
+
+	// 尝试占用 id, 已被其他进程占用时返回 false
+	claim(ctx context.Context, id int64) (bool, error)
+}
+
+// 可以主动通知租约失效的存储, 例如 etcd 的 watch
+type leaseWatcher interface {
+	// 租约失效时返回的通道中可以读取到错误
+	watch(ctx context.Context, id int64) <-chan error
+}
+
+// 由服务端决定到期时间的存储, 例如 broker 返回的租约
+type leaseExpiry interface {
+	// 租约当前的到期时间
+	expires() time.Time
+}
+
+// 从共享存储中租用的机器节点ID, 后台定期续约, 使用完毕之后需要调用 Close 释放
+// 续约失败超过 ttl, 被其他进程占用或调用 Close 时关闭 Lost, 此时应停止使用该ID生成ID
+type Lease struct {
+	id     int64
+	store  leaseStore
+	ttl    time.Duration
+	onLost func(id int64, err error)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	lost   chan struct{}
+
+	mu      sync.Mutex
+	err     error
+	closed  bool
+	renewed time.Time
+}
+
+// 从随机位置开始依次尝试占用机器节点ID, 占用成功之后开始续约
+func acquire(ctx context.Context, store claimStore, c config) (*Lease, error) {
+	n := c.mask() + 1
+	start := randomInt63() % n
+
+	for i := int64(0); i < n; i++ {
+		id := (start + i) % n
+		claimed := time.Now()
+		ok, err := store.claim(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return newLease(store, id, claimed, c), nil
+		}
+	}
+	return nil, ErrNoFreeID
+}
+
+// 为已经占用的 id 创建 Lease 并开始续约, claimed 为发送占用请求之前的时间
+// 存储按收到请求的时间计算有效期, 从发送之前开始计算不会晚于存储中的到期时间
+func newLease(store leaseStore, id int64, claimed time.Time, c config) *Lease {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Lease{
+		id:     id,
+		store:  store,
+		ttl:    c.ttl,
+		onLost: c.onLost,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		lost:   make(chan struct{}),
+
+		renewed: claimed,
+	}
+
+	// 返回之前开始监听, 以免错过之后的失效
+	var watch <-chan error
+	if w, ok := store.(leaseWatcher); ok {
+		watch = w.watch(ctx, id)
+	}
+	go l.keepAlive(ctx, watch)
+	return l
+}
+
+// 租用的机器节点ID
+func (l *Lease) ID() int64 {
+	return l.id
+}
+
+// 返回租用的机器节点ID的 Provider
+func (l *Lease) Provider() Provider {
+	return func() (int64, error) {
+		if err := l.Err(); err != nil {
+			return -1, err
+		}
+		return l.id, nil
+	}
+}
+
+// 续约失败或调用 Close 时关闭, Close 在释放机器节点ID之前关闭
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// 停止续约(Close 或租约失效)之后关闭
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// 续约失败的原因, Close 之后返回 ErrLeaseReleased, 租约有效时返回nil
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// 租约的到期时间, 每次续约成功之后延长
+func (l *Lease) Expires() time.Time {
+	if e, ok := l.store.(leaseExpiry); ok {
+		return e.expires()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.renewed.Add(l.ttl)
+}
+
+// 停止续约并释放机器节点ID, 释放之前关闭 Lost, 使共用该租约的 Node 先停止生成ID
+// 不会调用 WithOnLost 设置的回调
+func (l *Lease) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	l.cancel()
+	<-l.done
+
+	// 到期的计时器可能同时调用 fail, 在锁内判断
+	l.mu.Lock()
+	lost := l.err != nil
+	if !lost {
+		l.err = ErrLeaseReleased
+		close(l.lost)
+	}
+	l.mu.Unlock()
+
+	if lost {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), l.ttl)
+	defer cancel()
+	return l.store.release(ctx, l.id)
+}
+
+// 续约失败时重试的最短间隔
+const minBackoff = 100 * time.Millisecond
+
+// 每隔 ttl/3 (上下浮动10%)续约一次, 失败时从 minBackoff 开始指数退避重试
+// 到达 Expires 或存储返回 ErrLeaseLost 时租约失效, 到期时即使续约请求还未返回也立即关闭 Lost
+func (l *Lease) keepAlive(ctx context.Context, watch <-chan error) {
+	defer close(l.done)
+
+	interval := l.ttl / 3
+	backoff := time.Duration(0)
+
+	// 不依赖续约请求返回, 到期时立即失效
+	deadline := time.AfterFunc(time.Until(l.Expires()), func() {
+		l.fail(fmt.Errorf("%w: expired", ErrLeaseLost))
+	})
+	defer deadline.Stop()
+
+	// 从缓存恢复的租约可能即将到期, 提前第一次续约
+	delay := jitter(interval)
+	if remain := time.Until(l.Expires()) / 2; remain < delay {
+		delay = remain
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.lost:
+			return
+		case err := <-watch:
+			if ctx.Err() == nil {
+				l.fail(err)
+			}
+			return
+		case <-t.C:
+		}
+
+		// 续约请求不能超过剩余时间, 到期之后成功的续约也没有意义
+		timeout := interval
+		if remain := time.Until(l.Expires()); remain < timeout {
+			timeout = remain
+		}
+		rctx, cancel := context.WithTimeout(ctx, timeout)
+		sent := time.Now()
+		err := l.store.renew(rctx, l.id)
+		cancel()
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err == nil:
+			l.mu.Lock()
+			lost := l.err != nil
+			if !lost {
+				l.renewed = sent
+			}
+			l.mu.Unlock()
+			// 请求期间已经到期
+			if lost {
+				return
+			}
+			deadline.Reset(time.Until(l.Expires()))
+			backoff = 0
+			t.Reset(jitter(interval))
+			continue
+		case errors.Is(err, ErrLeaseLost):
+			l.fail(err)
+			return
+		}
+
+		// 剩余时间不足以再重试一次时放弃
+		remain := time.Until(l.Expires())
+		if remain <= 0 {
+			l.fail(fmt.Errorf("%w: %v", ErrLeaseLost, err))
+			return
+		}
+
+		backoff *= 2
+		if backoff < minBackoff {
+			backoff = minBackoff
+		}
+		if backoff > interval {
+			backoff = interval
+		}
+		delay := jitter(backoff)
+		if delay > remain {
+			delay = remain
+		}
+		t.Reset(delay)
+	}
+}
+
+// 在 d 的基础上随机浮动10%, 避免大量实例同时续约
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	span := int64(d) / 5
+	if span == 0 {
+		return d
+	}
+	return d - time.Duration(span/2) + time.Duration(randomInt63()%span)
+}
+
+// 标记租约失效并通知, 只有第一次调用生效
+// 到期的计时器与 keepAlive 可能同时调用
+func (l *Lease) fail(err error) {
+	l.mu.Lock()
+	if l.err != nil {
+		l.mu.Unlock()
+		return
+	}
+	l.err = err
+	close(l.lost)
+	l.mu.Unlock()
+
+	if l.onLost != nil {
+		l.onLost(l.id, err)
+	}
+}
+
+// 生成区分租约持有者的随机令牌
+func newToken() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func randomInt63() int64 {
+	var b [8]byte
+	rand.Read(b[:])
+	return int64(binary.BigEndian.Uint64(b[:]) >> 1)
+}
+
+func leaseKey(prefix string, id int64) string {
+	return prefix + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,316 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 内存中的租约存储, renewErr 非 nil 时续约返回该错误, 占用与续约在 delay 之后完成
+type memoryStore struct {
+	mu       sync.Mutex
+	claimed  map[int64]bool
+	renews   int
+	releases int
+	renewErr error
+	delay    time.Duration
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{claimed: make(map[int64]bool)}
+}
+
+func (s *memoryStore) claim(_ context.Context, id int64) (bool, error) {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claimed[id] {
+		return false, nil
+	}
+	s.claimed[id] = true
+	return true, nil
+}
+
+func (s *memoryStore) renew(context.Context, int64) error {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renews++
+	return s.renewErr
+}
+
+func (s *memoryStore) release(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releases++
+	delete(s.claimed, id)
+	return nil
+}
+
+func (s *memoryStore) setRenewErr(err error) {
+	s.mu.Lock()
+	s.renewErr = err
+	s.mu.Unlock()
+}
+
+func (s *memoryStore) counts() (renews, releases int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.renews, s.releases
+}
+
+// 可以主动通知租约失效的存储
+type watchStore struct {
+	*memoryStore
+	lost chan error
+}
+
+func (s watchStore) watch(context.Context, int64) <-chan error {
+	return s.lost
+}
+
+// 记录 WithOnLost 回调
+type lostRecorder struct {
+	mu  sync.Mutex
+	id  int64
+	err error
+}
+
+func (r *lostRecorder) option() Option {
+	return WithOnLost(func(id int64, err error) {
+		r.mu.Lock()
+		r.id, r.err = id, err
+		r.mu.Unlock()
+	})
+}
+
+func (r *lostRecorder) get() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.id, r.err
+}
+
+func waitLost(t *testing.T, l *Lease, timeout time.Duration) {
+	t.Helper()
+	select {
+	case <-l.Lost():
+	case <-time.After(timeout):
+		t.Fatalf("lease not lost after %s", timeout)
+	}
+	select {
+	case <-l.Done():
+	case <-time.After(timeout):
+		t.Fatalf("keepAlive not stopped after %s", timeout)
+	}
+}
+
+func TestLeaseKeepAlive(t *testing.T) {
+	store := newMemoryStore()
+	l, err := acquire(context.Background(), store, newConfig([]Option{WithBits(4), WithTTL(150 * time.Millisecond)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	if renews, _ := store.counts(); renews < 3 {
+		t.Errorf("got %d renews in 400ms with ttl 150ms, want at least 3", renews)
+	}
+	select {
+	case <-l.Lost():
+		t.Fatalf("lease lost: %v", l.Err())
+	default:
+	}
+	if time.Until(l.Expires()) <= 0 {
+		t.Errorf("lease expired at %s", l.Expires())
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	<-l.Done()
+	if _, releases := store.counts(); releases != 1 {
+		t.Errorf("got %d releases, want 1", releases)
+	}
+	select {
+	case <-l.Lost():
+	default:
+		t.Error("Lost not closed after Close")
+	}
+	if err := l.Err(); !errors.Is(err, ErrLeaseReleased) {
+		t.Errorf("Err after Close: got %v, want ErrLeaseReleased", err)
+	}
+	if _, err := l.Provider()(); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Provider after Close: got %v, want ErrLeaseLost", err)
+	}
+}
+
+// 存储按收到请求的时间计算有效期, 到期时间从发送请求之前开始计算
+func TestLeaseExpiresBeforeRequest(t *testing.T) {
+	store := newMemoryStore()
+	store.delay = 50 * time.Millisecond
+	const ttl = 300 * time.Millisecond
+
+	sent := time.Now()
+	l, err := acquire(context.Background(), store, newConfig([]Option{WithBits(4), WithTTL(ttl)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if e := l.Expires(); !e.Before(sent.Add(ttl + store.delay/2)) {
+		t.Errorf("Expires: got %s after the request was sent, want at most %s", e.Sub(sent), ttl)
+	}
+
+	// 等待一次续约完成
+	for renews, _ := store.counts(); renews == 0; renews, _ = store.counts() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if e := l.Expires(); !e.Before(time.Now().Add(ttl - store.delay/2)) {
+		t.Errorf("Expires after renew: got %s from now, want at most %s", time.Until(e), ttl-store.delay)
+	}
+}
+
+// 存储返回 ErrLeaseLost 时立即失效, 不再释放
+func TestLeaseLost(t *testing.T) {
+	store := newMemoryStore()
+	var rec lostRecorder
+	l, err := acquire(context.Background(), store, newConfig([]Option{WithBits(4), WithTTL(150 * time.Millisecond), rec.option()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.setRenewErr(ErrLeaseLost)
+	waitLost(t, l, time.Second)
+
+	if !errors.Is(l.Err(), ErrLeaseLost) {
+		t.Errorf("Err: got %v, want ErrLeaseLost", l.Err())
+	}
+	if id, err := rec.get(); id != l.ID() || !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("onLost: got (%d, %v), want (%d, ErrLeaseLost)", id, err, l.ID())
+	}
+	if _, err := l.Provider()(); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Provider: got %v, want ErrLeaseLost", err)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, releases := store.counts(); releases != 0 {
+		t.Errorf("got %d releases of a lost lease, want 0", releases)
+	}
+}
+
+// 续约持续失败时退避重试, 超过 ttl 之后失效
+func TestLeaseRetryUntilExpired(t *testing.T) {
+	store := newMemoryStore()
+	l, err := acquire(context.Background(), store, newConfig([]Option{WithBits(4), WithTTL(300 * time.Millisecond)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	store.setRenewErr(errors.New("store unavailable"))
+	start := time.Now()
+	waitLost(t, l, 2*time.Second)
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("lease lost after %s, want it to retry until the ttl expires", elapsed)
+	}
+	if renews, _ := store.counts(); renews < 2 {
+		t.Errorf("got %d renews, want retries before giving up", renews)
+	}
+	if !errors.Is(l.Err(), ErrLeaseLost) {
+		t.Errorf("Err: got %v, want ErrLeaseLost", l.Err())
+	}
+}
+
+// 不响应 ctx 的存储, 续约在 hang 之后才返回
+type hangStore struct {
+	*memoryStore
+	hang time.Duration
+}
+
+func (s hangStore) renew(ctx context.Context, id int64) error {
+	time.Sleep(s.hang)
+	return s.memoryStore.renew(ctx, id)
+}
+
+// 续约请求没有返回时到期同样失效
+func TestLeaseExpiresWhileRenewing(t *testing.T) {
+	store := hangStore{memoryStore: newMemoryStore(), hang: 2 * time.Second}
+	const ttl = 300 * time.Millisecond
+	start := time.Now()
+	l, err := acquire(context.Background(), store, newConfig([]Option{WithBits(4), WithTTL(ttl)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lease not lost while the renew request hangs")
+	}
+	if elapsed := time.Since(start); elapsed < ttl {
+		t.Errorf("lease lost after %s, want it to last the ttl %s", elapsed, ttl)
+	}
+	if !errors.Is(l.Err(), ErrLeaseLost) {
+		t.Errorf("Err: got %v, want ErrLeaseLost", l.Err())
+	}
+}
+
+// 存储主动通知的失效
+func TestLeaseWatch(t *testing.T) {
+	store := watchStore{memoryStore: newMemoryStore(), lost: make(chan error, 1)}
+	l, err := acquire(context.Background(), store, newConfig([]Option{WithBits(4), WithTTL(time.Minute)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	store.lost <- ErrLeaseLost
+	waitLost(t, l, time.Second)
+	if !errors.Is(l.Err(), ErrLeaseLost) {
+		t.Errorf("Err: got %v, want ErrLeaseLost", l.Err())
+	}
+}
+
+// 并发占用时每个ID只租给一个 Lease, 全部占用之后返回 ErrNoFreeID
+func TestAcquireConcurrent(t *testing.T) {
+	store := newMemoryStore()
+	c := newConfig([]Option{WithBits(4), WithTTL(time.Minute)})
+
+	const workers = 32
+	leases := make([]*Lease, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			leases[i], errs[i] = acquire(context.Background(), store, c)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool)
+	free := 0
+	for i, l := range leases {
+		if errs[i] != nil {
+			if !errors.Is(errs[i], ErrNoFreeID) {
+				t.Errorf("acquire: %v", errs[i])
+			}
+			free++
+			continue
+		}
+		defer l.Close()
+		if seen[l.ID()] {
+			t.Errorf("ID %d leased twice", l.ID())
+		}
+		seen[l.ID()] = true
+	}
+	if len(seen) != 16 || free != workers-16 {
+		t.Errorf("got %d leases and %d ErrNoFreeID, want 16 and %d", len(seen), free, workers-16)
+	}
+}
@@ -0,0 +1,66 @@
+package machineid
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strings"
+)
+
+// 缺省跳过的虚拟网卡
+var defaultExclude = []string{
+	"docker", "veth", "br-", "virbr", "vmnet", "vboxnet",
+	"cni", "flannel", "cali", "cilium", "tun", "tap", "utun", "awdl", "llw",
+}
+
+// 使用主网卡硬件地址的哈希值作为机器节点ID, 位数由 WithBits 指定
+// 按照名称排序之后选择第一个有硬件地址的非回环网卡, 跳过 WithExclude 指定的虚拟网卡
+// 指定 WithTruncate 时直接使用地址的低位
+func FromMAC(opts ...Option) Provider {
+	c := newConfig(opts)
+	return func() (int64, error) {
+		mac, err := primaryMAC(c.exclude)
+		if err != nil {
+			return -1, err
+		}
+
+		if c.truncate {
+			var b [8]byte
+			copy(b[8-len(mac):], mac)
+			return int64(binary.BigEndian.Uint64(b[:])) & c.mask(), nil
+		}
+
+		h := fnv.New64a()
+		h.Write(mac)
+		return int64(h.Sum64()) & c.mask(), nil
+	}
+}
+
+func primaryMAC(exclude []string) (net.HardwareAddr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ifaces, func(i, j int) bool {
+		return ifaces[i].Name < ifaces[j].Name
+	})
+
+next:
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) < 6 || len(iface.HardwareAddr) > 8 {
+			continue
+		}
+		for _, p := range exclude {
+			if strings.HasPrefix(iface.Name, p) {
+				continue next
+			}
+		}
+		for _, b := range iface.HardwareAddr {
+			if b != 0 {
+				return iface.HardwareAddr, nil
+			}
+		}
+	}
+	return nil, ErrNoMAC
+}
@@ -0,0 +1,286 @@
+// 获取机器节点ID的常用方式, 例如私有IP地址, MAC地址, 主机名, 环境变量以及从 Redis 等共享存储租用
+//
+//	id, err := machineid.FromPrivateIP()()
+//	node, err := snowflake.NewNode(id)
+package machineid
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ming913/snowflake"
+)
+
+// 返回机器节点ID
+type Provider func() (int64, error)
+
+var (
+	// 没有可用的私有IPv4地址
+	ErrNoPrivateIP = errors.New("no private IPv4 address")
+
+	// 没有可用的网卡硬件地址
+	ErrNoMAC = errors.New("no hardware address")
+
+	// 环境变量未设置
+	ErrEnvNotSet = errors.New("environment variable not set")
+
+	// 不在 Kubernetes 集群中运行
+	ErrNotInKubernetes = errors.New("not running in a Kubernetes cluster")
+
+	// 局域网中有其他节点使用相同的机器节点ID
+	ErrDuplicateMachineID = errors.New("duplicate machine ID")
+
+	// 本机不匹配任何映射规则
+	ErrNoMatchingRule = errors.New("no matching machine ID rule")
+
+	// Chain 中所有的 Provider 都失败
+	ErrAllProvidersFailed = errors.New("all machine ID providers failed")
+
+	// 所有机器节点ID都已被占用
+	ErrNoFreeID = errors.New("no free machine ID")
+
+	// 租约已过期或被其他进程占用, 与 snowflake.ErrLeaseLost 相同
+	ErrLeaseLost = snowflake.ErrLeaseLost
+
+	// 租约已通过 Close 释放, errors.Is(err, ErrLeaseLost) 同样成立
+	ErrLeaseReleased = fmt.Errorf("%w: released", ErrLeaseLost)
+)
+
+type config struct {
+	bits uint8
+
+	// FromMAC 使用
+	truncate bool
+	exclude  []string
+
+	// FromHostname 使用
+	ordinal bool
+
+	// 租约使用
+	ttl    time.Duration
+	prefix string
+	onLost func(id int64, err error)
+
+	// Consul 使用
+	checks     []string
+	datacenter string
+
+	// 通过IP地址在网段中的偏移计算ID
+	cidr string
+
+	// 云平台元数据服务使用
+	endpoint string
+	timeout  time.Duration
+	ipSuffix bool
+
+	// FromFile 文件不存在时使用
+	delegate Provider
+
+	// 数据库使用
+	table  string
+	dollar bool
+
+	// Retry 使用
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	// Probe 与 Announce 使用
+	probeAddr string
+
+	// broker 使用
+	cache string
+	owner string
+
+	// FromRules 使用
+	label string
+}
+
+// Provider 的可选配置项
+type Option func(*config)
+
+// 机器节点ID使用的位数, 缺省为包级变量 snowflake.MachineBits
+func WithBits(bits uint8) Option {
+	return func(c *config) {
+		c.bits = bits
+	}
+}
+
+// FromMAC 直接截取地址的低位, 缺省使用地址的哈希值
+func WithTruncate() Option {
+	return func(c *config) {
+		c.truncate = true
+	}
+}
+
+// FromMAC 跳过名称以 prefixes 开头的网卡, 会替换缺省的虚拟网卡列表
+func WithExclude(prefixes ...string) Option {
+	return func(c *config) {
+		c.exclude = prefixes
+	}
+}
+
+// FromHostname 优先使用主机名末尾的序号, 例如 StatefulSet 的 "api-7" 为 7
+// 没有序号时使用主机名的哈希值
+func WithOrdinal() Option {
+	return func(c *config) {
+		c.ordinal = true
+	}
+}
+
+// 租约的有效期, 每 ttl/3 续约一次, 缺省为30秒
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// 租约在存储中使用的键前缀, 缺省为 "snowflake/machine/"
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// 租约失效时调用 fn, 例如停止使用对应的 Node
+func WithOnLost(fn func(id int64, err error)) Option {
+	return func(c *config) {
+		c.onLost = fn
+	}
+}
+
+// Consul 会话关联的健康检查, 检查失败时会话失效并自动释放机器节点ID
+func WithHealthChecks(checks ...string) Option {
+	return func(c *config) {
+		c.checks = checks
+	}
+}
+
+// 租约的键位于前缀之下的数据中心目录中, 每个数据中心独立分配机器节点ID
+// 通常与 snowflake.NewTwitterNode 或 DatacenterBits 一起使用
+func WithDatacenter(dc string) Option {
+	return func(c *config) {
+		c.datacenter = dc
+	}
+}
+
+// 使用IP地址在网段 cidr 中的偏移作为机器节点ID, 例如 Kubernetes 的 Pod 网段 "10.244.0.0/16"
+func WithCIDR(cidr string) Option {
+	return func(c *config) {
+		c.cidr = cidr
+	}
+}
+
+// 元数据服务的地址, 缺省为各云平台的标准地址, 一般只在测试或使用代理时设置
+func WithEndpoint(endpoint string) Option {
+	return func(c *config) {
+		c.endpoint = endpoint
+	}
+}
+
+// 访问元数据服务或 broker 的超时时间, 缺省为2秒
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// 使用私有IP地址的低位代替实例ID的哈希值
+func WithIPSuffix() Option {
+	return func(c *config) {
+		c.ipSuffix = true
+	}
+}
+
+// FromFile 第一次启动时通过 p 分配机器节点ID, 缺省随机分配
+// FromRules 通过 p 计算区间内的偏移
+func WithDelegate(p Provider) Option {
+	return func(c *config) {
+		c.delegate = p
+	}
+}
+
+// 数据库中的表名, 缺省为 snowflake_machine, 建表语句参考 SQLSchemaFor
+func WithTable(table string) Option {
+	return func(c *config) {
+		c.table = table
+	}
+}
+
+// 使用 $1, $2 形式的占位符(PostgreSQL), 缺省使用 ?
+func WithDollarPlaceholders() Option {
+	return func(c *config) {
+		c.dollar = true
+	}
+}
+
+// Retry 最多尝试的次数, 缺省为5次, 小于1时按1次
+func WithAttempts(n int) Option {
+	return func(c *config) {
+		c.attempts = n
+	}
+}
+
+// Retry 第一次重试之前等待 initial, 之后每次加倍直到 max, 缺省为100毫秒与5秒
+func WithBackoff(initial, max time.Duration) Option {
+	return func(c *config) {
+		c.backoff = initial
+		c.maxBackoff = max
+	}
+}
+
+// Probe 发送探测的地址, Announce 监听其中的端口, 缺省为 "255.255.255.255:47474"
+func WithProbeAddr(addr string) Option {
+	return func(c *config) {
+		c.probeAddr = addr
+	}
+}
+
+// 把 broker 发放的租约缓存在文件 path 中, 进程重启时优先续约缓存的租约,
+// broker 暂时不可用时在租约到期之前继续使用
+func WithCache(path string) Option {
+	return func(c *config) {
+		c.cache = path
+	}
+}
+
+// 向 broker 申请租约时报告的持有者, 缺省为主机名
+func WithOwner(owner string) Option {
+	return func(c *config) {
+		c.owner = owner
+	}
+}
+
+// FromRules 匹配规则使用的本机标签, 例如 "blue", 缺省读取环境变量 SNOWFLAKE_LABEL
+func WithLabel(label string) Option {
+	return func(c *config) {
+		c.label = label
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		bits:    snowflake.MachineBits,
+		exclude: defaultExclude,
+		ttl:     30 * time.Second,
+		prefix:  "snowflake/machine/",
+		timeout: 2 * time.Second,
+		table:   "snowflake_machine",
+
+		attempts:   5,
+		backoff:    100 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+
+		probeAddr: "255.255.255.255:47474",
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// 机器节点ID的掩码
+func (c config) mask() int64 {
+	return 1<<c.bits - 1
+}
@@ -0,0 +1,58 @@
+package machineid
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// 请求元数据服务, 返回去掉首尾空白的响应内容
+func fetch(ctx context.Context, c config, method, url string, header map[string]string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata %s %s: %s", method, url, resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// 把字符串折叠到机器节点ID的位数范围
+func hashID(s string, c config) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return int64(h.Sum64()) & c.mask()
+}
+
+// 使用IPv4地址的低位
+func ipSuffix(s string, c config) (int64, error) {
+	ip := net.ParseIP(s).To4()
+	if ip == nil {
+		return -1, fmt.Errorf("invalid IPv4 address %q", s)
+	}
+	return int64(binary.BigEndian.Uint32(ip)) & c.mask(), nil
+}
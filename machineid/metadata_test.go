@@ -0,0 +1,94 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 元数据服务没有响应时, 取消 ctx 同时取消进行中的请求
+func TestMetadataContext(t *testing.T) {
+	canceled := make(chan struct{}, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		canceled <- struct{}{}
+	}))
+	defer srv.Close()
+
+	providers := map[string]ContextProvider{
+		"EC2":   FromEC2Context(WithEndpoint(srv.URL)),
+		"ECS":   FromECSContext(WithEndpoint(srv.URL)),
+		"GCP":   FromGCPMetadataContext(WithEndpoint(srv.URL)),
+		"Azure": FromAzureIMDSContext(WithEndpoint(srv.URL)),
+	}
+	for name, p := range providers {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			if _, err := p(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("got %v, want context.DeadlineExceeded", err)
+			}
+			select {
+			case <-canceled:
+			case <-time.After(time.Second):
+				t.Fatal("the metadata request was not canceled")
+			}
+		})
+	}
+}
+
+func TestMetadataGCP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/id":
+			w.Write([]byte("1234567890\n"))
+		case "/computeMetadata/v1/instance/network-interfaces/0/ip":
+			w.Write([]byte("10.0.1.7"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	id, err := FromGCPMetadataContext(WithEndpoint(srv.URL), WithBits(8))(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := hashID("1234567890", newConfig([]Option{WithBits(8)})); id != want {
+		t.Errorf("instance id: got %d, want %d", id, want)
+	}
+
+	id, err = FromGCPMetadata(WithEndpoint(srv.URL), WithBits(8), WithIPSuffix())()
+	if err != nil || id != 7 {
+		t.Errorf("ip suffix: got (%d, %v), want 7", id, err)
+	}
+}
+
+// 次数小于1时仍然尝试1次并返回原始错误
+func TestRetryAttempts(t *testing.T) {
+	cause := errors.New("unavailable")
+	for _, n := range []int{0, -1} {
+		calls := 0
+		p := Retry(func(context.Context) (int64, error) {
+			calls++
+			return -1, cause
+		}, WithAttempts(n))
+
+		_, err := p(context.Background())
+		if !errors.Is(err, cause) || strings.Contains(err.Error(), "%!") {
+			t.Errorf("WithAttempts(%d): got %v, want %v", n, err, cause)
+		}
+		if calls != 1 {
+			t.Errorf("WithAttempts(%d): got %d calls, want 1", n, calls)
+		}
+	}
+}
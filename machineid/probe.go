@@ -0,0 +1,164 @@
+package machineid
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// 探测报文: magic(4) + 类型(1) + 机器节点ID(8) + 随机数(16) + 主机名
+const (
+	probeMagic  = "SNFK"
+	probeHeader = 4 + 1 + 8 + 16
+
+	probeRequest byte = 1
+	probeClaim   byte = 2
+)
+
+// 在局域网中广播机器节点ID id, 在 WithTimeout 指定的时间内收到其他节点的声明时返回 ErrDuplicateMachineID
+// 其他节点需要通过 Announce 响应探测, 没有响应不代表ID一定没有重复
+func Probe(ctx context.Context, id int64, opts ...Option) error {
+	c := newConfig(opts)
+
+	dst, err := net.ResolveUDPAddr("udp4", c.probeAddr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	req := encodeProbe(probeRequest, id, processNonce)
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+
+	// 每隔一段时间重发一次, 以免单个报文丢失
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		t := time.NewTicker(c.timeout / 4)
+		defer t.Stop()
+		for {
+			conn.WriteToUDP(req, dst)
+			select {
+			case <-t.C:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				conn.SetReadDeadline(time.Now())
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, 512)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return nil
+			}
+			return err
+		}
+
+		typ, peer, peerNonce, host, ok := decodeProbe(buf[:n])
+		if ok && typ == probeClaim && peer == id && !bytes.Equal(peerNonce, processNonce) {
+			return fmt.Errorf("%w: %d is already used by %s (%s)", ErrDuplicateMachineID, id, host, from)
+		}
+	}
+}
+
+// 响应其他节点对机器节点ID的探测, 通过 Close 停止
+type Announcer struct {
+	conn *net.UDPConn
+	id   int64
+	done chan struct{}
+}
+
+// 在 WithProbeAddr 指定的端口上监听探测, 收到相同机器节点ID的探测时回复声明
+// 同一台主机上只能有一个 Announcer 监听同一端口
+func Announce(id int64, opts ...Option) (*Announcer, error) {
+	c := newConfig(opts)
+
+	addr, err := net.ResolveUDPAddr("udp4", c.probeAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: addr.Port})
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Announcer{conn: conn, id: id, done: make(chan struct{})}
+	go a.serve()
+	return a, nil
+}
+
+func (a *Announcer) serve() {
+	defer close(a.done)
+
+	claim := encodeProbe(probeClaim, a.id, processNonce)
+	buf := make([]byte, 512)
+	for {
+		n, from, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+
+		// 忽略本进程发出的探测
+		typ, id, nonce, _, ok := decodeProbe(buf[:n])
+		if ok && typ == probeRequest && id == a.id && !bytes.Equal(nonce, processNonce) {
+			a.conn.WriteToUDP(claim, from)
+		}
+	}
+}
+
+// 停止响应探测
+func (a *Announcer) Close() error {
+	err := a.conn.Close()
+	<-a.done
+	return err
+}
+
+// 区分进程的随机数, 同一进程中的 Probe 与 Announce 不会互相冲突
+var processNonce = newNonce()
+
+func newNonce() []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b, uint64(randomInt63()))
+	binary.BigEndian.PutUint64(b[8:], uint64(randomInt63()))
+	return b
+}
+
+func encodeProbe(typ byte, id int64, nonce []byte) []byte {
+	host, _ := os.Hostname()
+	b := make([]byte, probeHeader, probeHeader+len(host))
+	copy(b, probeMagic)
+	b[4] = typ
+	binary.BigEndian.PutUint64(b[5:], uint64(id))
+	copy(b[13:], nonce)
+	return append(b, host...)
+}
+
+func decodeProbe(b []byte) (typ byte, id int64, nonce []byte, host string, ok bool) {
+	if len(b) < probeHeader || string(b[:4]) != probeMagic {
+		return 0, 0, nil, "", false
+	}
+	return b[4], int64(binary.BigEndian.Uint64(b[5:])), b[13:probeHeader], string(b[probeHeader:]), true
+}
@@ -0,0 +1,66 @@
+package machineid
+
+import (
+	"context"
+	"time"
+)
+
+// Lease 使用的 Redis 命令, 可以通过简单的适配器使用 go-redis 等客户端, 例如:
+//
+//	func (a adapter) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+//		return a.c.SetNX(ctx, key, value, ttl).Result()
+//	}
+//
+//	func (a adapter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+//		return a.c.Eval(ctx, script, keys, args...).Result()
+//	}
+type RedisClient interface {
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// 只有持有者可以续约与释放
+const (
+	redisRenewScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+
+	redisReleaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+)
+
+// 通过 Redis SETNX 租用一个空闲的机器节点ID, 键为 WithPrefix 指定的前缀加上ID, 有效期由 WithTTL 指定
+// 后台定期续约, 使用完毕之后请调用 Lease.Close 释放
+func NewRedisLease(ctx context.Context, client RedisClient, opts ...Option) (*Lease, error) {
+	c := newConfig(opts)
+	return acquire(ctx, &redisStore{
+		client: client,
+		prefix: c.prefix,
+		ttl:    c.ttl,
+		token:  newToken(),
+	}, c)
+}
+
+type redisStore struct {
+	client RedisClient
+	prefix string
+	ttl    time.Duration
+	token  string
+}
+
+func (s *redisStore) claim(ctx context.Context, id int64) (bool, error) {
+	return s.client.SetNX(ctx, leaseKey(s.prefix, id), s.token, s.ttl)
+}
+
+func (s *redisStore) renew(ctx context.Context, id int64) error {
+	res, err := s.client.Eval(ctx, redisRenewScript, []string{leaseKey(s.prefix, id)}, s.token, s.ttl.Milliseconds())
+	if err != nil {
+		return err
+	}
+	if n, ok := res.(int64); !ok || n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *redisStore) release(ctx context.Context, id int64) error {
+	_, err := s.client.Eval(ctx, redisReleaseScript, []string{leaseKey(s.prefix, id)}, s.token)
+	return err
+}
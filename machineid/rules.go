@@ -0,0 +1,156 @@
+package machineid
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/ming913/snowflake"
+)
+
+// 机器节点ID的映射规则, 匹配的主机使用区间 [Min, Max] 中的ID
+// CIDR 与 Label 至少设置一个, 同时设置时都匹配才生效
+type Rule struct {
+	// 本机的某个IPv4地址位于该网段中, 例如 "10.1.0.0/16"
+	CIDR string `json:"cidr,omitempty"`
+
+	// 本机的标签, 参考 WithLabel
+	Label string `json:"label,omitempty"`
+
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+}
+
+// 区间中ID的数量
+func (r Rule) size() int64 {
+	return r.Max - r.Min + 1
+}
+
+// 从 JSON 读取映射规则并检查, 例如:
+//
+//	[
+//		{"cidr": "10.1.0.0/16", "min": 0, "max": 255},
+//		{"cidr": "10.2.0.0/16", "min": 256, "max": 511},
+//		{"label": "blue", "min": 512, "max": 767},
+//		{"label": "green", "min": 768, "max": 1023}
+//	]
+func LoadRules(r io.Reader, opts ...Option) ([]Rule, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, err
+	}
+	if err := ValidateRules(rules, opts...); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// 检查映射规则, 区间必须在机器节点ID的范围之内并且互不重叠, 以免不同集群分配到相同的ID
+func ValidateRules(rules []Rule, opts ...Option) error {
+	c := newConfig(opts)
+	for i, r := range rules {
+		if r.CIDR == "" && r.Label == "" {
+			return fmt.Errorf("rule %d: cidr or label is required", i)
+		}
+		if r.CIDR != "" {
+			if _, _, err := net.ParseCIDR(r.CIDR); err != nil {
+				return fmt.Errorf("rule %d: %w", i, err)
+			}
+		}
+		if r.Min < 0 || r.Min > r.Max || r.Max > c.mask() {
+			return fmt.Errorf("%w: rule %d: range %d-%d must be within 0 and %d",
+				snowflake.ErrMachineIDOutOfRange, i, r.Min, r.Max, c.mask())
+		}
+		for j := 0; j < i; j++ {
+			if o := rules[j]; r.Min <= o.Max && o.Min <= r.Max {
+				return fmt.Errorf("rule %d: range %d-%d overlaps rule %d: %d-%d", i, r.Min, r.Max, j, o.Min, o.Max)
+			}
+		}
+	}
+	return nil
+}
+
+// 按顺序使用第一条与本机匹配的规则, 返回区间起点加上偏移, 没有匹配的规则时返回 ErrNoMatchingRule
+// 偏移缺省为本机IP地址在规则网段中的偏移, 只有标签的规则使用主机名的哈希值,
+// 也可以通过 WithDelegate 指定, 例如 WithDelegate(FromHostname(WithOrdinal())), 偏移必须小于区间大小
+func FromRules(rules []Rule, opts ...Option) Provider {
+	c := newConfig(opts)
+	if c.label == "" {
+		c.label = os.Getenv("SNOWFLAKE_LABEL")
+	}
+
+	return func() (int64, error) {
+		if err := ValidateRules(rules, opts...); err != nil {
+			return -1, err
+		}
+		ips, err := localIPv4s()
+		if err != nil {
+			return -1, err
+		}
+
+		for _, r := range rules {
+			if r.Label != "" && r.Label != c.label {
+				continue
+			}
+
+			var ip net.IP
+			if r.CIDR != "" {
+				if ip = matchCIDR(ips, r.CIDR); ip == nil {
+					continue
+				}
+			}
+
+			off, err := ruleOffset(r, ip, c)
+			if err != nil {
+				return -1, err
+			}
+			return r.Min + off, nil
+		}
+		return -1, fmt.Errorf("%w: label %q, addresses %v", ErrNoMatchingRule, c.label, ips)
+	}
+}
+
+// 返回 ips 中第一个位于 cidr 网段中的地址
+func matchCIDR(ips []net.IP, cidr string) net.IP {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if network.Contains(ip) {
+			return ip
+		}
+	}
+	return nil
+}
+
+// 本机在规则区间中的偏移
+func ruleOffset(r Rule, ip net.IP, c config) (int64, error) {
+	var off int64
+	switch {
+	case c.delegate != nil:
+		id, err := c.delegate()
+		if err != nil {
+			return -1, err
+		}
+		off = id
+	case ip != nil:
+		_, network, _ := net.ParseCIDR(r.CIDR)
+		off = int64(binary.BigEndian.Uint32(ip) - binary.BigEndian.Uint32(network.IP.To4()))
+	default:
+		name, err := os.Hostname()
+		if err != nil {
+			return -1, err
+		}
+		return hashID(name, config{bits: 63}) % r.size(), nil
+	}
+
+	if off < 0 || off >= r.size() {
+		return -1, fmt.Errorf("%w: offset %d must be less than %d for range %d-%d",
+			snowflake.ErrMachineIDOutOfRange, off, r.size(), r.Min, r.Max)
+	}
+	return off, nil
+}
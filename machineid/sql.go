@@ -0,0 +1,120 @@
+package machineid
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 机器节点ID注册表的结构, 主键保证同一ID只有一个持有者
+// expires_at 为 Unix 毫秒时间, 过期的ID可以被其他进程回收
+// 使用 WithTable 指定其他表名时请使用 SQLSchemaFor
+const SQLSchema = "CREATE TABLE snowflake_machine " + sqlColumns
+
+const sqlColumns = `(
+	machine_id INT         NOT NULL PRIMARY KEY,
+	owner      VARCHAR(64) NOT NULL,
+	expires_at BIGINT      NOT NULL
+)`
+
+// 返回表名为 table 的注册表结构, 与 WithTable 配合使用, table 按原样写入语句, 不会转义
+func SQLSchemaFor(table string) string {
+	return "CREATE TABLE " + table + " " + sqlColumns
+}
+
+// 通过数据库表(参考 SQLSchema)租用空闲或已过期的机器节点ID, 适用于 PostgreSQL 与 MySQL 等
+// 通过主键约束占用ID, 后台按照 WithTTL 定期更新 expires_at 作为心跳, 表名由 WithTable 指定
+// 过期时间使用本机时钟, ttl 应远大于各主机之间的时钟误差
+func NewSQLLease(ctx context.Context, db *sql.DB, opts ...Option) (*Lease, error) {
+	c := newConfig(opts)
+	return acquire(ctx, &sqlStore{
+		db:    db,
+		table: c.table,
+		ttl:   c.ttl,
+		owner: newToken(),
+		ph:    placeholders(c.dollar),
+	}, c)
+}
+
+type sqlStore struct {
+	db    *sql.DB
+	table string
+	ttl   time.Duration
+	owner string
+	ph    func(q string) string
+}
+
+func (s *sqlStore) expires() int64 {
+	return time.Now().Add(s.ttl).UnixNano() / int64(time.Millisecond)
+}
+
+func (s *sqlStore) claim(ctx context.Context, id int64) (bool, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+
+	// 回收已过期的ID
+	res, err := s.db.ExecContext(ctx,
+		s.ph("UPDATE "+s.table+" SET owner = ?, expires_at = ? WHERE machine_id = ? AND expires_at < ?"),
+		s.owner, s.expires(), id, now)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return true, nil
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		s.ph("INSERT INTO "+s.table+" (machine_id, owner, expires_at) VALUES (?, ?, ?)"),
+		id, s.owner, s.expires())
+	if err == nil {
+		return true, nil
+	}
+
+	// 主键冲突说明已被占用, 其他错误原样返回
+	var owner string
+	if qerr := s.db.QueryRowContext(ctx,
+		s.ph("SELECT owner FROM "+s.table+" WHERE machine_id = ?"), id).Scan(&owner); qerr == nil {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s *sqlStore) renew(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx,
+		s.ph("UPDATE "+s.table+" SET expires_at = ? WHERE machine_id = ? AND owner = ?"),
+		s.expires(), id, s.owner)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *sqlStore) release(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx,
+		s.ph("DELETE FROM "+s.table+" WHERE machine_id = ? AND owner = ?"), id, s.owner)
+	return err
+}
+
+// 返回把 ? 替换为数据库占位符的函数
+func placeholders(dollar bool) func(q string) string {
+	if !dollar {
+		return func(q string) string { return q }
+	}
+	return func(q string) string {
+		var b strings.Builder
+		n := 0
+		for i := 0; i < len(q); i++ {
+			if q[i] == '?' {
+				n++
+				b.WriteString("$" + strconv.Itoa(n))
+				continue
+			}
+			b.WriteByte(q[i])
+		}
+		return b.String()
+	}
+}
@@ -0,0 +1,18 @@
+package machineid
+
+import "testing"
+
+func TestSQLSchemaFor(t *testing.T) {
+	if got := SQLSchemaFor("snowflake_machine"); got != SQLSchema {
+		t.Errorf("default table: got %q, want SQLSchema %q", got, SQLSchema)
+	}
+
+	want := "CREATE TABLE app.id_leases (\n" +
+		"\tmachine_id INT         NOT NULL PRIMARY KEY,\n" +
+		"\towner      VARCHAR(64) NOT NULL,\n" +
+		"\texpires_at BIGINT      NOT NULL\n" +
+		")"
+	if got := SQLSchemaFor("app.id_leases"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package machineid
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lease 使用的 ZooKeeper 操作, 可以通过适配器使用 github.com/go-zookeeper/zk, 例如:
+//
+//	func (a adapter) CreateEphemeralSequential(path string, data []byte) (string, error) {
+//		return a.conn.Create(path, data, zk.FlagEphemeral|zk.FlagSequence, zk.WorldACL(zk.PermAll))
+//	}
+//
+// 父节点需要预先创建, SessionExpired 在会话过期时关闭通道, 断线重连期间会话有效时不应关闭
+type ZooKeeperClient interface {
+	CreateEphemeralSequential(path string, data []byte) (string, error)
+	Children(path string) ([]string, error)
+	Exists(path string) (bool, error)
+	Delete(path string) error
+	SessionExpired() <-chan struct{}
+}
+
+// ZooKeeper 顺序节点的名称前缀
+const zkNodePrefix = "id-"
+
+// 通过 ZooKeeper 临时顺序节点占用机器节点ID, 节点位于 "/" + WithPrefix 指定的路径之下
+// 机器节点ID为节点序号的低位, 与更早创建的存活节点冲突时删除并重新创建
+// 会话过期时临时节点被删除, 关闭 Lease.Lost 并调用 WithOnLost 指定的函数; 断线期间按照 WithTTL 容忍续约失败
+func NewZooKeeperLease(ctx context.Context, client ZooKeeperClient, opts ...Option) (*Lease, error) {
+	c := newConfig(opts)
+	dir := "/" + strings.Trim(c.prefix, "/")
+	n := c.mask() + 1
+
+	for i := int64(0); i <= n; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		created := time.Now()
+		path, err := client.CreateEphemeralSequential(dir+"/"+zkNodePrefix, nil)
+		if err != nil {
+			return nil, err
+		}
+		seq, err := zkSequence(path[strings.LastIndexByte(path, '/')+1:])
+		if err != nil {
+			client.Delete(path)
+			return nil, err
+		}
+
+		children, err := client.Children(dir)
+		if err != nil {
+			client.Delete(path)
+			return nil, err
+		}
+
+		id := seq % n
+		if !zkConflict(children, seq, id, n) {
+			return newLease(&zkStore{client: client, path: path}, id, created, c), nil
+		}
+		if err := client.Delete(path); err != nil {
+			return nil, err
+		}
+	}
+	return nil, ErrNoFreeID
+}
+
+// 是否有序号更小的存活节点使用相同的机器节点ID
+func zkConflict(children []string, seq, id, n int64) bool {
+	for _, name := range children {
+		s, err := zkSequence(name)
+		if err == nil && s < seq && s%n == id {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析顺序节点名称中的序号
+func zkSequence(name string) (int64, error) {
+	if !strings.HasPrefix(name, zkNodePrefix) {
+		return -1, fmt.Errorf("unexpected znode %q", name)
+	}
+	return strconv.ParseInt(name[len(zkNodePrefix):], 10, 64)
+}
+
+type zkStore struct {
+	client ZooKeeperClient
+	path   string
+}
+
+// 临时节点存在即租约有效
+func (s *zkStore) renew(ctx context.Context, id int64) error {
+	ok, err := s.client.Exists(s.path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *zkStore) release(ctx context.Context, id int64) error {
+	return s.client.Delete(s.path)
+}
+
+func (s *zkStore) watch(ctx context.Context, id int64) <-chan error {
+	ch := make(chan error, 1)
+	expired := s.client.SessionExpired()
+	go func() {
+		select {
+		case <-expired:
+			ch <- ErrLeaseLost
+		case <-ctx.Done():
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,133 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// 实现 github.com/vmihailenco/msgpack/v5.Marshaler, 不需要引入 msgpack
+// 编码为 MessagePack 整数, 按照数值大小使用最短的格式
+func (f ID) MarshalMsgpack() ([]byte, error) {
+	return appendMsgpackInt(nil, int64(f)), nil
+}
+
+// 实现 msgpack.Unmarshaler, 支持所有整数格式, 超出 int64 时返回 ErrOverflow
+func (f *ID) UnmarshalMsgpack(b []byte) error {
+	i, err := parseMsgpackInt(b)
+	if err != nil {
+		return err
+	}
+	*f = ID(i)
+	return nil
+}
+
+// 实现 msgpack.Marshaler, Valid 为 false 时为 nil
+func (n NullID) MarshalMsgpack() ([]byte, error) {
+	if !n.Valid {
+		return []byte{msgpackNil}, nil
+	}
+	return n.ID.MarshalMsgpack()
+}
+
+// 实现 msgpack.Unmarshaler, nil 时 Valid 为 false
+func (n *NullID) UnmarshalMsgpack(b []byte) error {
+	if len(b) == 1 && b[0] == msgpackNil {
+		n.ID, n.Valid = 0, false
+		return nil
+	}
+	if err := n.ID.UnmarshalMsgpack(b); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// MessagePack 的格式编号
+const (
+	msgpackNil    byte = 0xc0
+	msgpackUint8  byte = 0xcc
+	msgpackUint16 byte = 0xcd
+	msgpackUint32 byte = 0xce
+	msgpackUint64 byte = 0xcf
+	msgpackInt8   byte = 0xd0
+	msgpackInt16  byte = 0xd1
+	msgpackInt32  byte = 0xd2
+	msgpackInt64  byte = 0xd3
+)
+
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(b, byte(i))
+	case i < 0 && i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(b, msgpackInt8, byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		b = append(b, msgpackInt16, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-2:], uint16(i))
+		return b
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		b = append(b, msgpackInt32, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], uint32(i))
+		return b
+	}
+	b = append(b, msgpackInt64, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(b[len(b)-8:], uint64(i))
+	return b
+}
+
+func parseMsgpackInt(b []byte) (int64, error) {
+	if len(b) == 0 {
+		return 0, fmt.Errorf("empty msgpack value")
+	}
+
+	c := b[0]
+	if c <= 0x7f || c >= 0xe0 {
+		if len(b) != 1 {
+			return 0, fmt.Errorf("invalid msgpack integer")
+		}
+		return int64(int8(c)), nil
+	}
+
+	var size int
+	switch c {
+	case msgpackUint8, msgpackInt8:
+		size = 1
+	case msgpackUint16, msgpackInt16:
+		size = 2
+	case msgpackUint32, msgpackInt32:
+		size = 4
+	case msgpackUint64, msgpackInt64:
+		size = 8
+	default:
+		return 0, fmt.Errorf("cannot unmarshal msgpack type 0x%02x into snowflake.ID", c)
+	}
+	if len(b) != 1+size {
+		return 0, fmt.Errorf("invalid msgpack integer")
+	}
+
+	data := b[1:]
+	switch c {
+	case msgpackUint8:
+		return int64(data[0]), nil
+	case msgpackUint16:
+		return int64(binary.BigEndian.Uint16(data)), nil
+	case msgpackUint32:
+		return int64(binary.BigEndian.Uint32(data)), nil
+	case msgpackUint64:
+		u := binary.BigEndian.Uint64(data)
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("%w: snowflake ID %d", ErrOverflow, u)
+		}
+		return int64(u), nil
+	case msgpackInt8:
+		return int64(int8(data[0])), nil
+	case msgpackInt16:
+		return int64(int16(binary.BigEndian.Uint16(data))), nil
+	case msgpackInt32:
+		return int64(int32(binary.BigEndian.Uint32(data))), nil
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
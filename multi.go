@@ -0,0 +1,77 @@
+package snowflake
+
+import (
+	"context"
+)
+
+// NewMultiNode 缺省最多缓存的机器节点数量
+const defaultMultiNodeSize = 1024
+
+// 代表多个机器节点生成ID, 每个机器节点独立维护时间与自增序列
+// 适用于同时为多个逻辑节点提供ID的服务, 基于 NodePool, 超过容量时淘汰最久未使用的机器节点
+type MultiNode struct {
+	pool *NodePool
+
+	layout
+}
+
+// 返回一个新的 MultiNode, 所有机器节点共用 opts 指定的配置, 最多缓存1024个机器节点, 参考 NewMultiNodeSize
+func NewMultiNode(opts ...Option) (*MultiNode, error) {
+	return NewMultiNodeSize(defaultMultiNodeSize, opts...)
+}
+
+// 返回一个最多缓存 size 个机器节点的 MultiNode, size <= 0 时不限制
+// 被淘汰的机器节点再次使用时重新创建, 从淘汰之前最后的时间之后开始生成, 不会重复
+// 每个机器节点不能共用一个租约, 设置了 WithLease 时返回 ErrSharedLease
+func NewMultiNodeSize(size int, opts ...Option) (*MultiNode, error) {
+	pool, err := NewNodePool(size, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiNode{pool: pool, layout: pool.layout}, nil
+}
+
+// 代表 machineID 生成唯一ID, 第一次使用某个机器节点时创建对应的 Node
+// 等待与错误处理与 Node.GenerateContext 相同, 关闭之后返回 ErrPoolClosed
+func (m *MultiNode) GenerateFor(machineID int64) (ID, error) {
+	node, err := m.pool.Get(machineID)
+	if err != nil {
+		return -1, err
+	}
+	return node.GenerateContext(context.Background())
+}
+
+// 关闭并移除 machineID 对应的 Node, 之后再次使用时重新创建, 参考 NodePool.Remove
+func (m *MultiNode) Remove(machineID int64) error {
+	return m.pool.Remove(machineID)
+}
+
+// 当前缓存的机器节点数量
+func (m *MultiNode) Len() int {
+	return m.pool.Len()
+}
+
+// 关闭所有机器节点, 之后 GenerateFor 返回 ErrPoolClosed, 参考 NodePool.Close
+func (m *MultiNode) Close() error {
+	return m.pool.Close()
+}
+
+// 使用的位数划分与起始时间
+func (m *MultiNode) Layout() Layout {
+	return m.layout.spec
+}
+
+// 按照配置解析ID中的时间戳, 单位与 Layout.Unit 相同
+func (m *MultiNode) Time(id ID) int64 {
+	return m.layout.time(id)
+}
+
+// 按照配置解析ID中的机器节点
+func (m *MultiNode) Machine(id ID) int64 {
+	return m.layout.machine(id)
+}
+
+// 按照配置解析ID中的自增序列
+func (m *MultiNode) Step(id ID) int64 {
+	return m.layout.step(id)
+}
@@ -0,0 +1,154 @@
+package snowflake
+
+import "time"
+
+// NewNode 的可选配置项
+type Option func(*Config)
+
+// 使用指定的完整配置, 会覆盖在它之前设置的选项
+func WithConfig(c Config) Option {
+	return func(cfg *Config) {
+		*cfg = c
+	}
+}
+
+// 设置时间戳起始时间, 单位: 毫秒(ms)
+func WithEpoch(epoch int64) Option {
+	return func(c *Config) {
+		c.Layout.Epoch = epoch
+	}
+}
+
+// 设置ID的位数划分与起始时间
+func WithLayout(l Layout) Option {
+	return func(c *Config) {
+		c.Layout = l
+	}
+}
+
+// 设置时间戳的单位, 例如 time.Second
+func WithTimeUnit(unit time.Duration) Option {
+	return func(c *Config) {
+		c.Layout.Unit = unit
+	}
+}
+
+// 设置时间戳使用的位数, 为0时使用剩余的位数
+func WithTimeBits(bits uint8) Option {
+	return func(c *Config) {
+		c.Layout.TimeBits = bits
+	}
+}
+
+// 设置机器节点使用的位数
+func WithMachineBits(bits uint8) Option {
+	return func(c *Config) {
+		c.Layout.MachineBits = bits
+	}
+}
+
+// 设置自增ID使用的位数
+func WithStepBits(bits uint8) Option {
+	return func(c *Config) {
+		c.Layout.StepBits = bits
+	}
+}
+
+// 设置时钟源
+func WithClock(clock Clock) Option {
+	return func(c *Config) {
+		c.Clock = clock
+	}
+}
+
+// 使用函数作为时钟源, 等价于 WithClock(ClockFunc(now))
+func WithNowFunc(now func() int64) Option {
+	return WithClock(ClockFunc(now))
+}
+
+// 设置机器时间回退时的处理策略
+func WithRollbackPolicy(p RollbackPolicy) Option {
+	return func(c *Config) {
+		c.Rollback = p
+	}
+}
+
+// 设置允许的最大回退时间, 超过时返回 ErrClockMovedBackwards
+func WithMaxBackwardDrift(d time.Duration) Option {
+	return func(c *Config) {
+		c.MaxBackwardDrift = d
+	}
+}
+
+// 设置等待时自旋的阈值, 预计剩余时间不超过 d 时自旋, 否则休眠
+func WithSpinThreshold(d time.Duration) Option {
+	return func(c *Config) {
+		c.SpinThreshold = d
+	}
+}
+
+// 设置格式版本使用的位数与版本号
+func WithVersion(bits uint8, version int64) Option {
+	return func(c *Config) {
+		c.Layout.VersionBits = bits
+		c.Layout.Version = version
+	}
+}
+
+// 开启突发模式, 自增序列耗尽时最多借用 budget 的未来时间
+func WithBorrowBudget(budget time.Duration) Option {
+	return func(c *Config) {
+		c.BorrowBudget = budget
+	}
+}
+
+// 时间戳剩余可用时间不足 before 时调用一次 fn
+func WithOverflowWarning(before time.Duration, fn func(remaining time.Duration)) Option {
+	return func(c *Config) {
+		c.OverflowWarning = before
+		c.OnOverflowWarning = fn
+	}
+}
+
+// 设置机器节点ID的租约, 租约失效时调用 onLost, onLost 可以为nil
+func WithLease(l MachineLease, onLost func(err error)) Option {
+	return func(c *Config) {
+		c.Lease = l
+		c.OnLeaseLost = onLost
+	}
+}
+
+// 租约失效之后停止生成ID, 需要同时使用 WithLease
+func WithFencing() Option {
+	return func(c *Config) {
+		c.Fencing = true
+	}
+}
+
+// 设置最低位中使用随机数的位数
+func WithEntropyBits(bits uint8) Option {
+	return func(c *Config) {
+		c.Layout.EntropyBits = bits
+	}
+}
+
+// 设置租户使用的位数
+func WithTenantBits(bits uint8) Option {
+	return func(c *Config) {
+		c.Layout.TenantBits = bits
+	}
+}
+
+// 设置机器节点中作为区域使用的最高位位数
+func WithRegionBits(bits uint8) Option {
+	return func(c *Config) {
+		c.Layout.RegionBits = bits
+	}
+}
+
+// 设置机器节点中区域之后作为数据中心使用的位数
+func WithDatacenterBits(bits uint8) Option {
+	return func(c *Config) {
+		c.Layout.DatacenterBits = bits
+	}
+}
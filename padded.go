@@ -0,0 +1,70 @@
+package snowflake
+
+const decimalDigits = "0123456789"
+
+// 固定长度的编码, 不足时在高位补上字母表的第一个字符(即0)
+// 使用的字母表均按 ASCII 排序, 相同编码的字符串按字典序排序与数值排序相同, 可以用于 KV 存储的范围扫描
+// z-base-32 的字母表不按 ASCII 排序, 因此不提供固定长度的 Base32, 请使用 PaddedCrockford
+// 长度足以表示全部64位, 负数的ID按位视为无符号数, 排在所有非负数之后
+// 解析时使用对应的 Parse 函数即可, 例如 ParseBase58 会忽略高位的 "1"
+
+// 编码为20位10进制, 高位补0
+func (f ID) PaddedString() string {
+	return string(appendPadded(nil, uint64(f), decimalDigits))
+}
+
+// 编码为13个字符的 Crockford Base32, 高位补0
+func (f ID) PaddedCrockford() string {
+	return string(appendPadded(nil, uint64(f), encodeCrockfordMap))
+}
+
+// 编码为11个字符的 Base58, 高位补 "1"
+func (f ID) PaddedBase58() string {
+	return string(appendPadded(nil, uint64(f), encodeBase58Map))
+}
+
+// 编码为11个字符的 Base62, 高位补0
+func (f ID) PaddedBase62() string {
+	return string(appendPadded(nil, uint64(f), encodeBase62Map))
+}
+
+// 编码为20位10进制, 参考 ID.PaddedString
+func (u UnsignedID) PaddedString() string {
+	return string(appendPadded(nil, uint64(u), decimalDigits))
+}
+
+// 编码为13个字符的 Crockford Base32, 参考 ID.PaddedCrockford
+func (u UnsignedID) PaddedCrockford() string {
+	return string(appendPadded(nil, uint64(u), encodeCrockfordMap))
+}
+
+// 编码为11个字符的 Base58, 参考 ID.PaddedBase58
+func (u UnsignedID) PaddedBase58() string {
+	return string(appendPadded(nil, uint64(u), encodeBase58Map))
+}
+
+// 编码为11个字符的 Base62, 参考 ID.PaddedBase62
+func (u UnsignedID) PaddedBase62() string {
+	return string(appendPadded(nil, uint64(u), encodeBase62Map))
+}
+
+// 把 v 编码为固定长度之后追加到 dst
+func appendPadded(dst []byte, v uint64, alphabet string) []byte {
+	var b [64]byte
+	digits := appendUintBase(b[:0], v, alphabet)
+	for i := len(digits); i < paddedWidth(len(alphabet)); i++ {
+		dst = append(dst, alphabet[0])
+	}
+	return append(dst, digits...)
+}
+
+// base 进制表示任意64位无符号数需要的字符数
+func paddedWidth(base int) int {
+	n := 1
+	for limit := uint64(base); ; limit *= uint64(base) {
+		if limit > ^uint64(0)/uint64(base) {
+			return n + 1
+		}
+		n++
+	}
+}
@@ -0,0 +1,142 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ID的字符串编码, 用于 ParseAs
+type Encoding uint8
+
+const (
+	// 按照 Parse 的规则自动识别
+	EncodingAuto Encoding = iota
+
+	// 10进制, 与 ID.String 相同
+	EncodingDecimal
+
+	// 16进制, 与 ID.Hex 相同, 可以带 0x 前缀
+	EncodingHex
+
+	// 与 ID.Base2 相同
+	EncodingBase2
+
+	// 与 ID.Base32 相同
+	EncodingBase32
+
+	// 与 ID.Base36 相同
+	EncodingBase36
+
+	// 与 ID.Base58 相同
+	EncodingBase58
+
+	// 与 ID.Base62 相同
+	EncodingBase62
+
+	// 与 ID.Crockford 相同, 不参与自动识别
+	EncodingCrockford
+
+	// 与 ID.Base85 相同, 不参与自动识别
+	EncodingBase85
+
+	// 与 ID.Base64URL 相同, 不参与自动识别
+	EncodingBase64URL
+
+	// 与 ID.Base64 相同, 不参与自动识别
+	EncodingBase64
+)
+
+// 无法识别字符串的编码
+var ErrUnknownEncoding = errors.New("unknown snowflake ID encoding")
+
+// 自动识别编码并解析ID, 用于接收来自不同客户端的ID
+// 依次尝试: 0x 前缀的16进制, 10进制, Base58, Base62
+// 只包含数字的字符串总是按10进制解析, 同时符合 Base58 与 Base62 的字符串按 Base58 解析,
+// 编码确定时请使用 ParseAs
+func Parse(s string) (ID, error) {
+	return ParseAs(s, EncodingAuto)
+}
+
+// 按照指定的编码解析ID
+func ParseAs(s string, enc Encoding) (ID, error) {
+	switch enc {
+	case EncodingAuto:
+		return parseAuto(s)
+	case EncodingDecimal:
+		return ParseString(s)
+	case EncodingHex:
+		return ParseHex(s)
+	case EncodingBase2:
+		return ParseBase2(s)
+	case EncodingBase32:
+		return ParseBase32([]byte(s))
+	case EncodingBase36:
+		return ParseBase36(s)
+	case EncodingBase58:
+		return ParseBase58([]byte(s))
+	case EncodingBase62:
+		return ParseBase62([]byte(s))
+	case EncodingCrockford:
+		return ParseCrockford(s)
+	case EncodingBase85:
+		return ParseBase85(s)
+	case EncodingBase64URL:
+		return ParseBase64URL(s)
+	case EncodingBase64:
+		return ParseBase64(s)
+	}
+	return -1, fmt.Errorf("%w: %d", ErrUnknownEncoding, enc)
+}
+
+func parseAuto(s string) (ID, error) {
+	switch {
+	case len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X'):
+		return ParseHex(s)
+	case isDecimal(s):
+		return ParseString(s)
+	case inAlphabet(s, &decodeBase58Map):
+		return ParseBase58([]byte(s))
+	case inAlphabet(s, &decodeBase62Map):
+		return ParseBase62([]byte(s))
+	}
+	return -1, fmt.Errorf("%w: %q", ErrUnknownEncoding, s)
+}
+
+// 解析可以带 0x 前缀的16进制, 最多16个字符
+func parseHex(s string) (uint64, error) {
+	h := s
+	if len(h) > 2 && h[0] == '0' && (h[1] == 'x' || h[1] == 'X') {
+		h = h[2:]
+	}
+	if len(h) > 16 {
+		return 0, fmt.Errorf("%w: snowflake ID %q", ErrOverflow, s)
+	}
+	u, err := strconv.ParseUint(h, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidHex, s)
+	}
+	return u, nil
+}
+
+// 编码为固定16个字符的小写16进制并追加到 dst
+func appendHex(dst []byte, v uint64) []byte {
+	const digits = "0123456789abcdef"
+	for shift := 60; shift >= 0; shift -= 4 {
+		dst = append(dst, digits[v>>uint(shift)&0xF])
+	}
+	return dst
+}
+
+// s 不为空并且所有字符都在 decode 对应的字母表中
+func inAlphabet(s string, decode *[128]byte) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 128 || decode[s[i]] == 0xFF {
+			return false
+		}
+	}
+	return true
+}
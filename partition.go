@@ -0,0 +1,88 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// 把ID映射到 [0, n) 之间的分区, 相同的ID总是映射到相同的分区, 用于 Kafka 等按分区保序的消息队列
+// n 不大于0时返回0
+type Partitioner func(id ID, n int) int
+
+// 按机器节点分区, 同一个节点生成的ID位于同一个分区, 机器节点包括区域与数据中心
+func (l Layout) PartitionByMachine() Partitioner {
+	ll := newLayout(l)
+	return func(id ID, n int) int {
+		return partitionOf(ll.machine(id), n)
+	}
+}
+
+// 按时间窗口分区, 同一个 window 内生成的ID位于同一个分区, 相邻的窗口依次使用下一个分区
+// 窗口按照 Unix 时间对齐, 例如 time.Hour 为整点, window 不大于0时使用时间戳的单位
+func (l Layout) PartitionByTime(window time.Duration) Partitioner {
+	ll := newLayout(l)
+	if window <= 0 {
+		window = ll.unit
+	}
+	return func(id ID, n int) int {
+		return partitionOf(ll.time(id)*int64(ll.unit)/int64(window), n)
+	}
+}
+
+// 按ID的哈希值分区, 相邻的ID均匀分布到各个分区
+// 与 Kafka Java 客户端缺省分区器对8字节大端序键(即 IntBytes)使用 murmur2 的结果相同
+func PartitionByHash(id ID, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(id))
+	return int(murmur2(b[:])&0x7fffffff) % n
+}
+
+func partitionOf(v int64, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	p := v % int64(n)
+	if p < 0 {
+		p += int64(n)
+	}
+	return int(p)
+}
+
+// Kafka 使用的 murmur2 哈希
+func murmur2(b []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+
+	h := uint32(seed) ^ uint32(len(b))
+	for ; len(b) >= 4; b = b[4:] {
+		k := binary.LittleEndian.Uint32(b)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	switch len(b) {
+	case 3:
+		h ^= uint32(b[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(b[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(b[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
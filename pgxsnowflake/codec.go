@@ -0,0 +1,142 @@
+// 为 pgx v5 注册 snowflake.ID 与 snowflake.NullID 的 int8 编解码
+//
+// 不注册时 pgx 通过 sql.Scanner 与 driver.Valuer 转换, 每次都需要经过 interface{} 与类型判断,
+// 注册之后直接读写 int8 的二进制与文本格式, 并且可以使用 []snowflake.ID 读写 int8[] 列
+//
+//	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+//		pgxsnowflake.Register(conn.TypeMap())
+//		return nil
+//	}
+package pgxsnowflake
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ming913/snowflake"
+)
+
+// 在 m 中用 Codec 替换 int8 与 int8[] 的编解码, 并把 snowflake.ID 与 snowflake.NullID 的缺省类型设置为 int8
+func Register(m *pgtype.Map) {
+	t := &pgtype.Type{Name: "int8", OID: pgtype.Int8OID, Codec: Codec{}}
+	m.RegisterType(t)
+	m.RegisterType(&pgtype.Type{Name: "_int8", OID: pgtype.Int8ArrayOID, Codec: &pgtype.ArrayCodec{ElementType: t}})
+
+	m.RegisterDefaultPgType(snowflake.ID(0), "int8")
+	m.RegisterDefaultPgType(snowflake.NullID{}, "int8")
+	m.RegisterDefaultPgType([]snowflake.ID(nil), "_int8")
+}
+
+// int8 的编解码, 直接处理 snowflake.ID 与 snowflake.NullID, 其他类型交给 pgtype.Int8Codec
+type Codec struct {
+	pgtype.Int8Codec
+}
+
+func (c Codec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value interface{}) pgtype.EncodePlan {
+	switch value.(type) {
+	case snowflake.ID, snowflake.NullID:
+		switch format {
+		case pgtype.BinaryFormatCode:
+			return encodePlanBinary{}
+		case pgtype.TextFormatCode:
+			return encodePlanText{}
+		}
+	}
+	return c.Int8Codec.PlanEncode(m, oid, format, value)
+}
+
+func (c Codec) PlanScan(m *pgtype.Map, oid uint32, format int16, target interface{}) pgtype.ScanPlan {
+	switch target.(type) {
+	case *snowflake.ID, *snowflake.NullID:
+		switch format {
+		case pgtype.BinaryFormatCode:
+			return scanPlanBinary{}
+		case pgtype.TextFormatCode:
+			return scanPlanText{}
+		}
+	}
+	return c.Int8Codec.PlanScan(m, oid, format, target)
+}
+
+func (c Codec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	return c.Int8Codec.DecodeDatabaseSQLValue(m, oid, format, src)
+}
+
+func (c Codec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (interface{}, error) {
+	return c.Int8Codec.DecodeValue(m, oid, format, src)
+}
+
+// 返回要写入的ID, NULL 时 ok 为 false
+func idValue(value interface{}) (id snowflake.ID, ok bool) {
+	switch v := value.(type) {
+	case snowflake.ID:
+		return v, true
+	case snowflake.NullID:
+		return v.ID, v.Valid
+	}
+	return 0, false
+}
+
+type encodePlanBinary struct{}
+
+func (encodePlanBinary) Encode(value interface{}, buf []byte) ([]byte, error) {
+	id, ok := idValue(value)
+	if !ok {
+		return nil, nil
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(id))
+	return append(buf, b[:]...), nil
+}
+
+type encodePlanText struct{}
+
+func (encodePlanText) Encode(value interface{}, buf []byte) ([]byte, error) {
+	id, ok := idValue(value)
+	if !ok {
+		return nil, nil
+	}
+	return strconv.AppendInt(buf, int64(id), 10), nil
+}
+
+// 写入读取到的ID, src 为 NULL 时只有 NullID 可以接收
+func assign(target interface{}, src []byte, id snowflake.ID) error {
+	switch t := target.(type) {
+	case *snowflake.ID:
+		if src == nil {
+			return fmt.Errorf("cannot scan NULL into %T", target)
+		}
+		*t = id
+	case *snowflake.NullID:
+		*t = snowflake.NullID{ID: id, Valid: src != nil}
+	}
+	return nil
+}
+
+type scanPlanBinary struct{}
+
+func (scanPlanBinary) Scan(src []byte, target interface{}) error {
+	if src == nil {
+		return assign(target, nil, 0)
+	}
+	if len(src) != 8 {
+		return fmt.Errorf("invalid length for int8: %v", len(src))
+	}
+	return assign(target, src, snowflake.ID(binary.BigEndian.Uint64(src)))
+}
+
+type scanPlanText struct{}
+
+func (scanPlanText) Scan(src []byte, target interface{}) error {
+	if src == nil {
+		return assign(target, nil, 0)
+	}
+	n, err := strconv.ParseInt(string(src), 10, 64)
+	if err != nil {
+		return err
+	}
+	return assign(target, src, snowflake.ID(n))
+}
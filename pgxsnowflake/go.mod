@@ -0,0 +1,9 @@
+module github.com/ming913/snowflake/pgxsnowflake
+
+// pgx v5.11 要求 Go 1.25
+go 1.25.0
+
+require (
+	github.com/jackc/pgx/v5 v5.11.0
+	github.com/ming913/snowflake v0.1.0
+)
@@ -0,0 +1,245 @@
+package snowflake
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// 按机器节点缓存 Node 的池, 第一次使用时创建, 超过容量时淘汰最久未使用的 Node
+// 所有 Node 共用同一份配置, 用于代理或 broker 类型的部署
+// 之后创建的 Node 继承被淘汰的 Node 最后的时间与 GenerateAt 进度, 防止ID重复
+type NodePool struct {
+	mu      sync.Mutex
+	size    int
+	opts    []Option
+	nodes   map[int64]*list.Element
+	lru     *list.List
+	closed  bool
+	onEvict func(machineID int64, node *Node)
+
+	// 被淘汰的 Node 最后生成ID的时间, 重新创建的 Node 从这之后开始生成, 防止ID重复
+	evicted int64
+
+	// 第一个 Node 的创建时间与被淘汰的 Node 的 GenerateAt 进度, 重新创建的 Node 只回填更早的时间并从该进度继续
+	start    int64
+	histLast int64
+	histStep int64
+
+	layout
+}
+
+type poolEntry struct {
+	machineID int64
+	node      *Node
+}
+
+// 返回一个最多缓存 size 个 Node 的池, size <= 0 时不限制
+// 每个 Node 使用不同的机器节点ID, 不能共用一个租约, 设置了 WithLease 时返回 ErrSharedLease
+func NewNodePool(size int, opts ...Option) (*NodePool, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.Lease != nil {
+		return nil, ErrSharedLease
+	}
+
+	l := newLayout(c.Layout)
+	return &NodePool{
+		size:     size,
+		opts:     opts,
+		nodes:    make(map[int64]*list.Element),
+		lru:      list.New(),
+		evicted:  l.epoch,
+		histLast: l.epoch - 1,
+		histStep: l.stepMask,
+		layout:   l,
+	}, nil
+}
+
+// 设置 Node 被淘汰或移除时的回调, 在释放池的锁之后调用, 此时 Node 已经关闭
+func (p *NodePool) OnEvict(fn func(machineID int64, node *Node)) {
+	p.mu.Lock()
+	p.onEvict = fn
+	p.mu.Unlock()
+}
+
+// 返回 machineID 对应的 Node, 不存在时创建
+// 被淘汰的 Node 之后生成ID时转而使用池中 machineID 对应的 Node, 必要时重新创建, 调用者可以继续持有
+// 通过 Remove 移除或池关闭之后 Node 返回 ErrNodeClosed
+// 淘汰时关闭 Node 的错误被忽略
+func (p *NodePool) Get(machineID int64) (*Node, error) {
+	p.mu.Lock()
+	node, evicted, err := p.get(machineID)
+	onEvict := p.onEvict
+	p.mu.Unlock()
+
+	if onEvict != nil {
+		for _, entry := range evicted {
+			onEvict(entry.machineID, entry.node)
+		}
+	}
+	return node, err
+}
+
+func (p *NodePool) get(machineID int64) (*Node, []*poolEntry, error) {
+	if p.closed {
+		return nil, nil, ErrPoolClosed
+	}
+
+	if e, ok := p.nodes[machineID]; ok {
+		p.lru.MoveToFront(e)
+		return e.Value.(*poolEntry).node, nil, nil
+	}
+
+	node, err := NewNode(machineID, p.opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	node.after(p.evicted)
+	node.pool = p
+	node.poolID = machineID
+
+	// 之前的 Node 实时生成的时间范围不能回填
+	if p.start == 0 || node.start < p.start {
+		p.start = node.start
+	}
+	node.start = p.start
+	node.histLast, node.histStep = p.histLast, p.histStep
+
+	p.nodes[machineID] = p.lru.PushFront(&poolEntry{machineID: machineID, node: node})
+
+	var evicted []*poolEntry
+	for p.size > 0 && p.lru.Len() > p.size {
+		entry, _ := p.remove(p.lru.Back(), true)
+		evicted = append(evicted, entry)
+	}
+	return node, evicted, nil
+}
+
+// 从池中移除并关闭 machineID 对应的 Node, 返回关闭的错误
+// 之后该 Node 返回 ErrNodeClosed, 不会转而使用重新创建的 Node
+func (p *NodePool) Remove(machineID int64) error {
+	p.mu.Lock()
+	e, ok := p.nodes[machineID]
+	if !ok {
+		p.mu.Unlock()
+		return nil
+	}
+	entry, err := p.remove(e, false)
+	onEvict := p.onEvict
+	p.mu.Unlock()
+
+	if onEvict != nil {
+		onEvict(entry.machineID, entry.node)
+	}
+	return err
+}
+
+// 池中 Node 的数量
+func (p *NodePool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.lru.Len()
+}
+
+// 移除并关闭所有 Node, 之后 Get 返回 ErrPoolClosed, 返回第一个关闭失败的错误
+func (p *NodePool) Close() error {
+	p.mu.Lock()
+	var first error
+	var removed []*poolEntry
+	for p.lru.Len() > 0 {
+		entry, err := p.remove(p.lru.Back(), false)
+		if err != nil && first == nil {
+			first = err
+		}
+		removed = append(removed, entry)
+	}
+	p.closed = true
+	onEvict := p.onEvict
+	p.mu.Unlock()
+
+	if onEvict != nil {
+		for _, entry := range removed {
+			onEvict(entry.machineID, entry.node)
+		}
+	}
+	return first
+}
+
+// 移除并关闭 Node, evict 为 true 时之后的调用转而使用池中重新创建的 Node
+func (p *NodePool) remove(e *list.Element, evict bool) (*poolEntry, error) {
+	entry := p.lru.Remove(e).(*poolEntry)
+	delete(p.nodes, entry.machineID)
+
+	// 在关闭之前设置, 发现 Node 已关闭的调用者都能看到
+	if evict {
+		atomic.StoreInt32(&entry.node.evicted, 1)
+	}
+
+	// 先关闭再读取时间, 之前通过 Get 取得的 Node 不能再生成与新 Node 重复的ID
+	// exhaust 使正在生成的 goroutine 重新读取 state 并发现 Node 已关闭
+	err := entry.node.Close()
+	last, xerr := entry.node.exhaust()
+	if xerr != nil {
+		last = entry.node.last()
+	}
+	if last > p.evicted {
+		p.evicted = last
+	}
+
+	// 关闭之后 GenerateAt 不再更新进度
+	n := entry.node
+	n.histMu.Lock()
+	if n.histLast > p.histLast || n.histLast == p.histLast && n.histStep > p.histStep {
+		p.histLast, p.histStep = n.histLast, n.histStep
+	}
+	n.histMu.Unlock()
+	return entry, err
+}
+
+// 被淘汰之后池中 machineID 对应的 Node, 池已关闭或 Node 是被移除的时返回 false
+func (n *Node) successor() (*Node, bool) {
+	if n.pool == nil || atomic.LoadInt32(&n.evicted) == 0 {
+		return nil, false
+	}
+	next, err := n.pool.Get(n.poolID)
+	if err != nil {
+		return nil, false
+	}
+	return next, true
+}
+
+// 上次生成ID的时间
+func (n *Node) last() int64 {
+	return atomic.LoadInt64(&n.state)>>n.stepBits + n.epoch
+}
+
+// 保证之后生成的ID时间晚于 t, 只能在 Node 开始使用之前调用
+func (n *Node) after(t int64) {
+	if t >= n.last() {
+		atomic.StoreInt64(&n.state, (t-n.epoch)<<n.stepBits|n.stepMask)
+	}
+}
+
+// 使用的位数划分与起始时间
+func (p *NodePool) Layout() Layout {
+	return p.layout.spec
+}
+
+// 按照配置解析ID中的时间戳, 单位与 Layout.Unit 相同
+func (p *NodePool) Time(id ID) int64 {
+	return p.layout.time(id)
+}
+
+// 按照配置解析ID中的机器节点
+func (p *NodePool) Machine(id ID) int64 {
+	return p.layout.machine(id)
+}
+
+// 按照配置解析ID中的自增序列
+func (p *NodePool) Step(id ID) int64 {
+	return p.layout.step(id)
+}
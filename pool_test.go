@@ -0,0 +1,126 @@
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// 被淘汰的 Node 转而使用池中重新创建的 Node, 生成的ID继续递增
+func TestNodePoolEvict(t *testing.T) {
+	p, err := NewNodePool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	var evicted []int64
+	// 在释放锁之后调用, 回调中可以使用池
+	p.OnEvict(func(machineID int64, node *Node) {
+		evicted = append(evicted, machineID)
+		p.Len()
+	})
+
+	a, err := p.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := a.Generate()
+	if _, err := p.Get(2); err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("evicted: got %v, want [1]", evicted)
+	}
+
+	// 重新创建的 Node 从淘汰时的下一个时间单位开始, 需要等待
+	id, err := a.GenerateContext(context.Background())
+	if err != nil {
+		t.Fatalf("evicted Node: %v", err)
+	}
+	if id <= first || p.Machine(id) != 1 {
+		t.Errorf("evicted Node: got %d, want an ID of machine 1 after %d", id, first)
+	}
+	if next := a.Generate(); next <= id {
+		t.Errorf("evicted Node: got %d, want an ID after %d", next, id)
+	}
+
+	b, err := p.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.GenerateErr(); !errors.Is(err, ErrNodeClosed) {
+		t.Errorf("removed Node: got %v, want ErrNodeClosed", err)
+	}
+}
+
+// 池关闭之后被淘汰的 Node 返回 ErrNodeClosed
+func TestNodePoolClose(t *testing.T) {
+	p, err := NewNodePool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := p.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Get(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.GenerateErr(); !errors.Is(err, ErrNodeClosed) {
+		t.Errorf("evicted Node after Close: got %v, want ErrNodeClosed", err)
+	}
+	if _, err := p.Get(1); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Get after Close: got %v, want ErrPoolClosed", err)
+	}
+}
+
+// MultiNode 超过容量时淘汰机器节点, 重新创建之后生成的ID继续递增
+func TestMultiNodeSize(t *testing.T) {
+	m, err := NewMultiNodeSize(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := m.GenerateFor(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, machine := range []int64{2, 3} {
+		if _, err := m.GenerateFor(machine); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len: got %d, want 2", m.Len())
+	}
+
+	id, err := m.GenerateFor(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id <= first || m.Machine(id) != 1 {
+		t.Errorf("recreated machine: got %d, want an ID of machine 1 after %d", id, first)
+	}
+
+	if err := m.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len after Remove: got %d, want 1", m.Len())
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GenerateFor(2); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("closed: got %v, want ErrPoolClosed", err)
+	}
+}
@@ -0,0 +1,26 @@
+package snowflake
+
+import "fmt"
+
+// 返回一个属于指定区域的 Node, machineID 为区域内的机器编号
+// 机器节点 = region << (MachineBits - RegionBits) | machineID
+// region 与 machineID 分别按照 RegionBits 与剩余的位数校验
+func NewRegionNode(region, machineID int64, opts ...Option) (*Node, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l := newLayout(c.Layout)
+	if c.Layout.RegionBits == 0 {
+		return nil, fmt.Errorf("%w: RegionBits must be greater than 0", ErrInvalidLayout)
+	}
+	if region < 0 || region > l.regionMax {
+		return nil, fmt.Errorf("%w: Region must be between 0 and %d", ErrMachineIDOutOfRange, l.regionMax)
+	}
+	if max := int64(1)<<l.regionShift - 1; machineID < 0 || machineID > max {
+		return nil, fmt.Errorf("%w: MachineID must be between 0 and %d in region %d", ErrMachineIDOutOfRange, max, region)
+	}
+
+	return NewNode(region<<l.regionShift|machineID, opts...)
+}
@@ -0,0 +1,88 @@
+package snowflake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 按照格式版本保存的多个布局, 用于解析修改位数划分前后生成的ID
+// 所有布局的 VersionBits 与 Unsigned 必须相同
+type Registry struct {
+	mu      sync.RWMutex
+	layouts map[int64]layout
+
+	// 第一个注册的布局, 用于读取版本
+	first layout
+}
+
+// 返回一个新的 Registry 并注册 layouts
+func NewRegistry(layouts ...Layout) (*Registry, error) {
+	r := &Registry{layouts: make(map[int64]layout)}
+	for _, l := range layouts {
+		if err := r.Register(l); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// 注册布局, 同一版本重复注册时覆盖之前的布局
+func (r *Registry) Register(l Layout) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	if l.VersionBits == 0 {
+		return fmt.Errorf("%w: VersionBits must be greater than 0", ErrInvalidLayout)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.layouts) > 0 && (l.VersionBits != r.first.spec.VersionBits || l.Unsigned != r.first.spec.Unsigned) {
+		return fmt.Errorf("%w: VersionBits and Unsigned must match the registered layouts", ErrInvalidLayout)
+	}
+
+	c := newLayout(l)
+	if len(r.layouts) == 0 {
+		r.first = c
+	}
+	r.layouts[l.Version] = c
+	return nil
+}
+
+// 返回ID的格式版本对应的布局, 未注册时返回 ErrUnknownVersion
+func (r *Registry) Layout(id ID) (Layout, error) {
+	c, err := r.lookup(id)
+	return c.spec, err
+}
+
+// 按照ID的格式版本分解ID
+func (r *Registry) Decompose(id ID) (Parts, error) {
+	c, err := r.lookup(id)
+	if err != nil {
+		return Parts{}, err
+	}
+	return c.spec.Decompose(id), nil
+}
+
+// 按照ID的格式版本解析ID中的时间
+func (r *Registry) Timestamp(id ID) (time.Time, error) {
+	c, err := r.lookup(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.timestamp(id), nil
+}
+
+func (r *Registry) lookup(id ID) (layout, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v := r.first.version(id)
+	c, ok := r.layouts[v]
+	if !ok {
+		return layout{}, fmt.Errorf("%w: %d", ErrUnknownVersion, v)
+	}
+	return c, nil
+}
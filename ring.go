@@ -0,0 +1,413 @@
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	rejectError uint8 = iota
+	rejectWait
+)
+
+// 环形缓冲区取空时的处理策略
+type RejectPolicy struct {
+	mode uint8
+}
+
+var (
+	// 直接返回 ErrBufferEmpty, 缺省策略
+	RejectError = RejectPolicy{mode: rejectError}
+
+	// 等待后台填充
+	RejectWait = RejectPolicy{mode: rejectWait}
+)
+
+// 槽位状态
+const (
+	slotCanPut uint32 = iota
+	slotCanTake
+)
+
+type ringConfig struct {
+	boostPower    uint8
+	paddingFactor int
+	reject        RejectPolicy
+}
+
+// RingNode 的配置项
+type RingOption func(*ringConfig)
+
+// 缓冲区大小为每个时间单位的ID数量(1 << StepBits)左移 power 位, 缺省为3
+func WithBoostPower(power uint8) RingOption {
+	return func(c *ringConfig) {
+		c.boostPower = power
+	}
+}
+
+// 剩余ID数量低于缓冲区大小的 percent% 时开始后台填充, 缺省为50
+func WithPaddingFactor(percent int) RingOption {
+	return func(c *ringConfig) {
+		c.paddingFactor = percent
+	}
+}
+
+// 设置缓冲区取空时的处理策略
+func WithRejectPolicy(p RejectPolicy) RingOption {
+	return func(c *ringConfig) {
+		c.reject = p
+	}
+}
+
+// 预先生成ID并缓存在环形缓冲区中的节点, 与百度 uid-generator 的 CachedUidGenerator 相同
+// 后台goroutine按时间单位整批生成ID, 时间戳只依赖上次填充的时间递增, 可能超前于实际时间,
+// 以少许时间偏差换取远高于 Node 的吞吐量. 每个槽位独立标记状态, 取ID时只需要一次 CAS
+type RingNode struct {
+	// 下一个写入与下一个读取的位置, 分开放在不同的缓存行上
+	tail   uint64
+	_      [56]byte
+	cursor uint64
+	_      [56]byte
+
+	slots     []int64
+	flags     []uint32
+	size      uint64
+	mask      uint64
+	threshold uint64
+	reject    RejectPolicy
+
+	// 填充信号, 每次填充完成之后关闭 filled 并替换
+	fill   chan struct{}
+	mu     sync.Mutex
+	filled chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	// Shutdown 之后为1, 不再填充, 取空之后返回 ErrNodeClosed
+	draining int32
+
+	// 填充失败的原因(例如时间戳用尽), 由 mu 保护, 之后不再填充, 取空之后返回该错误
+	err error
+
+	// 上次填充使用的时间戳, 只由填充的goroutine访问
+	last int64
+
+	machine int64
+	clock   Clock
+
+	layout
+}
+
+// 返回一个新的 RingNode, 创建时同步填满缓冲区并启动后台填充
+// opts 与 NewNode 相同, 使用完毕之后需要调用 Close
+// 时间戳只依赖上次填充的时间递增, 不支持回退策略, 借用预算, 溢出预警与租约, 设置时返回 ErrUnsupportedOption
+func NewRingNode(machineID int64, ringOpts []RingOption, opts ...Option) (*RingNode, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := ringUnsupported(c); err != nil {
+		return nil, err
+	}
+
+	rc := ringConfig{boostPower: 3, paddingFactor: 50}
+	for _, opt := range ringOpts {
+		opt(&rc)
+	}
+	if rc.paddingFactor <= 0 || rc.paddingFactor >= 100 {
+		return nil, fmt.Errorf("padding factor must be between 1 and 99")
+	}
+
+	l := newLayout(c.Layout)
+	if machineID < 0 || machineID > l.machineMax {
+		return nil, fmt.Errorf("%w: MachineID must be between 0 and %d", ErrMachineIDOutOfRange, l.machineMax)
+	}
+	if int(l.stepBits)+int(rc.boostPower) > 30 {
+		return nil, fmt.Errorf("%w: StepBits + boost power must be less than or equal to 30", ErrInvalidLayout)
+	}
+
+	size := uint64(1) << (l.stepBits + rc.boostPower)
+	r := &RingNode{
+		slots:     make([]int64, size),
+		flags:     make([]uint32, size),
+		size:      size,
+		mask:      size - 1,
+		threshold: size * uint64(rc.paddingFactor) / 100,
+		reject:    rc.reject,
+		fill:      make(chan struct{}, 1),
+		filled:    make(chan struct{}),
+		done:      make(chan struct{}),
+		machine:   machineID,
+		clock:     c.Clock,
+		layout:    l,
+	}
+	if r.clock == nil {
+		r.clock = systemClock{unit: l.unit}
+	}
+	now := r.clock.Now()
+	if now < l.epoch || now-l.epoch > l.timeMask {
+		return nil, fmt.Errorf("%w: current time is out of the layout range", ErrTimestampOverflow)
+	}
+	r.last = now - 1
+
+	if err := r.padding(); err != nil {
+		return nil, err
+	}
+	go r.run()
+
+	return r, nil
+}
+
+// Config 中 RingNode 不支持的配置项
+func ringUnsupported(c Config) error {
+	var name string
+	switch {
+	case c.Rollback != (RollbackPolicy{}):
+		name = "Rollback"
+	case c.MaxBackwardDrift != 0:
+		name = "MaxBackwardDrift"
+	case c.SpinThreshold != 0:
+		name = "SpinThreshold"
+	case c.BorrowBudget != 0:
+		name = "BorrowBudget"
+	case c.OverflowWarning != 0 || c.OnOverflowWarning != nil:
+		name = "OverflowWarning"
+	case c.Lease != nil || c.OnLeaseLost != nil || c.Fencing:
+		name = "Lease"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: RingNode does not support %s", ErrUnsupportedOption, name)
+}
+
+// 从缓冲区取出唯一ID, 缓冲区为空时总是等待填充, 不受 RejectPolicy 影响
+// 无法继续生成时返回 -1, 例如已关闭或时间戳用尽, 需要错误原因请使用 GenerateContext
+func (r *RingNode) Generate() ID {
+	id, err := r.generate(context.Background(), true)
+	if err != nil {
+		return -1
+	}
+	return id
+}
+
+// 从缓冲区取出唯一ID, 缓冲区为空时按照 RejectPolicy 处理
+// RejectWait 策略等待时响应 ctx 的取消与超时, 填充失败之后取空时返回填充的错误
+func (r *RingNode) GenerateContext(ctx context.Context) (ID, error) {
+	return r.generate(ctx, r.reject.mode == rejectWait)
+}
+
+func (r *RingNode) generate(ctx context.Context, wait bool) (ID, error) {
+	for {
+		select {
+		case <-r.done:
+			return -1, ErrNodeClosed
+		default:
+		}
+
+		if id, ok := r.take(); ok {
+			return id, nil
+		}
+
+		if atomic.LoadInt32(&r.draining) != 0 {
+			return -1, ErrNodeClosed
+		}
+
+		r.mu.Lock()
+		filled, err := r.filled, r.err
+		r.mu.Unlock()
+
+		// 等待期间可能已经填充完成
+		if id, ok := r.take(); ok {
+			return id, nil
+		}
+		if err != nil {
+			return -1, err
+		}
+		if !wait {
+			return -1, ErrBufferEmpty
+		}
+
+		select {
+		case <-filled:
+		case <-r.done:
+			return -1, ErrNodeClosed
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		}
+	}
+}
+
+// 尝试取出一个ID, 缓冲区为空时返回 false 并触发填充
+func (r *RingNode) take() (ID, bool) {
+	for {
+		cur := atomic.LoadUint64(&r.cursor)
+		tail := atomic.LoadUint64(&r.tail)
+		if cur == tail {
+			r.trigger()
+			return -1, false
+		}
+
+		if !atomic.CompareAndSwapUint64(&r.cursor, cur, cur+1) {
+			continue
+		}
+
+		i := cur & r.mask
+		id := ID(atomic.LoadInt64(&r.slots[i]))
+		atomic.StoreUint32(&r.flags[i], slotCanPut)
+
+		if tail-cur-1 < r.threshold {
+			r.trigger()
+		}
+		return id, true
+	}
+}
+
+// 通知后台goroutine填充, 不阻塞
+func (r *RingNode) trigger() {
+	select {
+	case r.fill <- struct{}{}:
+	default:
+	}
+}
+
+func (r *RingNode) run() {
+	for {
+		select {
+		case <-r.fill:
+			// 生成出错时(例如时间戳溢出)停止填充, 取空之后返回该错误
+			if err := r.padding(); err != nil {
+				return
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// 按时间单位整批生成ID直到缓冲区填满, 只由一个goroutine调用
+// 返回错误时在唤醒等待的调用之前记录, 等待的调用不会错过
+func (r *RingNode) padding() (err error) {
+	defer func() {
+		r.mu.Lock()
+		if err != nil {
+			r.err = err
+		}
+		close(r.filled)
+		r.filled = make(chan struct{})
+		r.mu.Unlock()
+	}()
+
+	for {
+		if atomic.LoadInt32(&r.draining) != 0 {
+			return nil
+		}
+
+		// 剩余空间不足一个时间单位时不再开始新的时间戳, 避免丢弃ID使时间戳过快超前
+		free := r.size - (atomic.LoadUint64(&r.tail) - atomic.LoadUint64(&r.cursor))
+		if free < uint64(r.stepMask)+1 {
+			return nil
+		}
+
+		// 时钟回退时继续使用上次的时间戳之后的时间
+		t := r.last + 1
+		if now := r.clock.Now(); now > t {
+			t = now
+		}
+		if t-r.epoch > r.timeMask {
+			return ErrTimestampOverflow
+		}
+		r.last = t
+
+		for step := int64(0); step <= r.stepMask; step++ {
+			id := r.compose(t, r.machine, 0, step)
+			if r.entropyBits > 0 {
+				e, err := randomEntropy(r.entropyMask)
+				if err != nil {
+					return err
+				}
+				id |= ID(e)
+			}
+
+			// 槽位还未被读取, 当前时间单位剩余的ID被丢弃
+			if !r.put(id) {
+				return nil
+			}
+		}
+	}
+}
+
+// 写入一个ID, 缓冲区已满或槽位还未被读取时返回 false
+func (r *RingNode) put(id ID) bool {
+	tail := atomic.LoadUint64(&r.tail)
+	if tail-atomic.LoadUint64(&r.cursor) >= r.size {
+		return false
+	}
+
+	i := tail & r.mask
+	if atomic.LoadUint32(&r.flags[i]) != slotCanPut {
+		return false
+	}
+
+	atomic.StoreInt64(&r.slots[i], int64(id))
+	atomic.StoreUint32(&r.flags[i], slotCanTake)
+	atomic.StoreUint64(&r.tail, tail+1)
+	return true
+}
+
+// 停止后台填充, 之后的 Generate 返回 ErrNodeClosed
+func (r *RingNode) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	return nil
+}
+
+// 停止填充, 等待缓冲区中已生成的ID被取完或 ctx 结束之后调用 Close
+// 期间 Generate 继续返回缓冲区中的ID, 取空之后返回 ErrNodeClosed
+func (r *RingNode) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&r.draining, 1)
+	// 唤醒 RejectWait 策略下等待填充的调用
+	r.trigger()
+
+	t := time.NewTicker(time.Millisecond)
+	defer t.Stop()
+	for atomic.LoadUint64(&r.cursor) != atomic.LoadUint64(&r.tail) {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			r.Close()
+			return ctx.Err()
+		case <-r.done:
+			return nil
+		}
+	}
+	return r.Close()
+}
+
+// 缓冲区大小
+func (r *RingNode) Size() int {
+	return int(r.size)
+}
+
+// 使用的位数划分与起始时间
+func (r *RingNode) Layout() Layout {
+	return r.layout.spec
+}
+
+// 按照配置解析ID中的时间戳, 单位与 Layout.Unit 相同
+func (r *RingNode) Time(id ID) int64 {
+	return r.layout.time(id)
+}
+
+// 按照配置解析ID中的机器节点
+func (r *RingNode) Machine(id ID) int64 {
+	return r.layout.machine(id)
+}
+
+// 按照配置解析ID中的自增序列
+func (r *RingNode) Step(id ID) int64 {
+	return r.layout.step(id)
+}
@@ -0,0 +1,122 @@
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 缓冲区反复取空与填充时, 并发取出的ID互不重复, 每个goroutine 内严格递增
+func TestRingNodeConcurrent(t *testing.T) {
+	r, err := NewRingNode(1, []RingOption{WithBoostPower(2), WithRejectPolicy(RejectWait)}, WithStepBits(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ids := generateConcurrent(t, 16, 2000, r.Generate)
+	checkUnique(t, ids)
+}
+
+// Shutdown 期间继续返回缓冲区中的ID, 取空之后返回 ErrNodeClosed
+func TestRingNodeShutdown(t *testing.T) {
+	r, err := NewRingNode(1, []RingOption{WithRejectPolicy(RejectWait)}, WithStepBits(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 8
+	ids := make([][]ID, workers)
+	errs := make([]error, workers)
+	started := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			started <- struct{}{}
+			for {
+				id, err := r.GenerateContext(context.Background())
+				if err != nil {
+					errs[w] = err
+					return
+				}
+				ids[w] = append(ids[w], id)
+			}
+		}(w)
+	}
+	for w := 0; w < workers; w++ {
+		<-started
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	for w, err := range errs {
+		if !errors.Is(err, ErrNodeClosed) {
+			t.Errorf("worker %d: got %v, want ErrNodeClosed", w, err)
+		}
+	}
+	checkUnique(t, ids)
+
+	if _, err := r.GenerateContext(context.Background()); !errors.Is(err, ErrNodeClosed) {
+		t.Errorf("after Shutdown: got %v, want ErrNodeClosed", err)
+	}
+}
+
+// 时间戳用尽之后停止填充, 取空之后返回 ErrTimestampOverflow 而不是一直等待
+func TestRingNodeOverflow(t *testing.T) {
+	// 时钟停在最后一个可用的时间戳, 缓冲区只容纳一个时间单位, 取完之后时间戳用尽, 不再填充
+	l := Layout{TimeBits: 20, MachineBits: 10, StepBits: 6, Epoch: Epoch}
+	end := Epoch + 1<<20 - 1
+	r, err := NewRingNode(1, []RingOption{WithBoostPower(0), WithRejectPolicy(RejectWait)}, WithLayout(l), WithNowFunc(func() int64 { return end }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; ; i++ {
+		if _, err := r.GenerateContext(ctx); err != nil {
+			if !errors.Is(err, ErrTimestampOverflow) {
+				t.Fatalf("got %v, want ErrTimestampOverflow", err)
+			}
+			if i != 1<<6 {
+				t.Errorf("got %d IDs before overflow, want %d", i, 1<<6)
+			}
+			break
+		}
+	}
+	if id := r.Generate(); id != -1 {
+		t.Errorf("Generate after overflow: got %d, want -1", id)
+	}
+}
+
+// 不支持的配置项与超出布局范围的当前时间返回错误
+func TestNewRingNodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want error
+	}{
+		{"rollback", []Option{WithRollbackPolicy(ReturnError)}, ErrUnsupportedOption},
+		{"borrow budget", []Option{WithBorrowBudget(time.Millisecond)}, ErrUnsupportedOption},
+		{"lease", []Option{WithLease(newTestLease(), nil)}, ErrUnsupportedOption},
+		{"fencing", []Option{WithFencing()}, ErrUnsupportedOption},
+		{"before epoch", []Option{WithNowFunc(func() int64 { return Epoch - 1 })}, ErrTimestampOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRingNode(1, nil, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
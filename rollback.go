@@ -0,0 +1,39 @@
+package snowflake
+
+import "time"
+
+const (
+	rollbackWait uint8 = iota
+	rollbackError
+	rollbackBorrow
+)
+
+// 机器时间回退时的处理策略, 零值为一直等待到时间追上上次生成ID的时间
+type RollbackPolicy struct {
+	mode    uint8
+	maxWait time.Duration
+}
+
+var (
+	// 一直等待, 缺省策略
+	WaitForever = RollbackPolicy{mode: rollbackWait}
+
+	// 不等待, 直接返回 ErrClockBackwards
+	ReturnError = RollbackPolicy{mode: rollbackError}
+
+	// 继续使用上次的时间戳生成ID, 直到当前毫秒的自增序列耗尽
+	BorrowTime = RollbackPolicy{mode: rollbackBorrow}
+)
+
+// 回退时间不超过 d 时等待, 超过时返回 ErrClockBackwards
+func WaitFor(d time.Duration) RollbackPolicy {
+	return RollbackPolicy{mode: rollbackWait, maxWait: d}
+}
+
+// 回退 backwards 时是否允许等待
+func (p RollbackPolicy) canWait(backwards time.Duration) bool {
+	if p.mode != rollbackWait {
+		return false
+	}
+	return p.maxWait <= 0 || backwards <= p.maxWait
+}
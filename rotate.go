@@ -0,0 +1,54 @@
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// 当前使用的机器节点ID
+func (n *Node) MachineID() int64 {
+	return atomic.LoadInt64(&n.machine)
+}
+
+// 替换之后生成的ID使用新的机器节点ID, 用于租约重新分配了机器节点ID而不重启进程的场景
+// 替换时把当前时间单位的自增序列标记为耗尽, 并等待进入下一个时间单位之后返回,
+// 使用旧ID生成的ID的时间戳都不晚于替换时的时间戳, 使用新ID生成的ID都晚于该时间戳
+func (n *Node) SetMachineID(id int64) error {
+	if id < 0 || id > n.machineMax {
+		return fmt.Errorf("%w: MachineID must be between 0 and %d", ErrMachineIDOutOfRange, n.machineMax)
+	}
+
+	// 没有自增序列时同一时间单位内的ID只由随机数区分, 替换期间不允许生成
+	if n.ent != nil {
+		n.ent.mu.Lock()
+		defer n.ent.mu.Unlock()
+	}
+
+	atomic.StoreInt64(&n.machine, id)
+
+	t, err := n.exhaust()
+	if err != nil {
+		return err
+	}
+	return n.waitAfter(context.Background(), t)
+}
+
+// 把当前时间单位的自增序列标记为耗尽, 返回该时间单位, 之后 CAS 成功的ID的时间戳都晚于它
+func (n *Node) exhaust() (int64, error) {
+	for {
+		old := atomic.LoadInt64(&n.state)
+		t := old>>n.stepBits + n.epoch
+		if now := n.clock.Now(); now > t {
+			t = now
+		}
+		if t-n.epoch > n.timeMask {
+			return 0, ErrTimestampOverflow
+		}
+
+		fence := (t-n.epoch)<<n.stepBits | n.stepMask
+		if old == fence || atomic.CompareAndSwapInt64(&n.state, old, fence) {
+			return t, nil
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// 号段模式(与美团 Leaf-segment 相同)的ID生成器
+//
+// 每次从数据库租用一段连续的号段 [Start, End), 在内存中依次分配
+// 当前号段剩余不足一定比例时在后台预取下一个号段, 数据库短暂不可用时依然可以继续分配
+// 生成的ID不包含时间戳, 只保证唯一与单个进程内递增, 与 snowflake 包共用 ID 类型与编解码方法
+package segment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ming913/snowflake"
+)
+
+var (
+	// 号段表中没有对应的业务标识
+	ErrTagNotFound = errors.New("segment tag not found")
+
+	// 分配器返回的号段不合法
+	ErrInvalidRange = errors.New("invalid segment range")
+)
+
+// 一个号段, 可以分配的ID为 [Start, End)
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// 为业务标识 tag 租用新的号段, 多个进程同时调用时返回的号段不能重叠
+type Allocator interface {
+	Allocate(ctx context.Context, tag string) (Range, error)
+}
+
+// 实现 Allocator 的函数
+type AllocatorFunc func(ctx context.Context, tag string) (Range, error)
+
+func (f AllocatorFunc) Allocate(ctx context.Context, tag string) (Range, error) {
+	return f(ctx, tag)
+}
+
+type config struct {
+	prefetch float64
+}
+
+// Generator 的配置项
+type Option func(*config)
+
+// 当前号段剩余的比例低于 ratio 时预取下一个号段, 缺省为 0.9, 与 Leaf 相同
+func WithPrefetchRatio(ratio float64) Option {
+	return func(c *config) {
+		c.prefetch = ratio
+	}
+}
+
+// 号段模式的ID生成器, 双缓冲: 当前号段与预取的下一个号段
+type Generator struct {
+	tag      string
+	alloc    Allocator
+	prefetch float64
+
+	mu      sync.Mutex
+	cur     segment
+	next    *segment
+	loading chan struct{}
+	err     error
+}
+
+type segment struct {
+	value int64
+	Range
+}
+
+// 返回一个新的 Generator, 创建时同步租用第一个号段
+func New(ctx context.Context, alloc Allocator, tag string, opts ...Option) (*Generator, error) {
+	c := config{prefetch: 0.9}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.prefetch < 0 || c.prefetch > 1 {
+		return nil, fmt.Errorf("prefetch ratio must be between 0 and 1")
+	}
+
+	r, err := allocate(ctx, alloc, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Generator{
+		tag:      tag,
+		alloc:    alloc,
+		prefetch: c.prefetch,
+		cur:      segment{value: r.Start, Range: r},
+	}, nil
+}
+
+// 生成唯一ID, 号段用尽且下一个号段还未取到时等待
+func (g *Generator) Generate() (snowflake.ID, error) {
+	return g.GenerateContext(context.Background())
+}
+
+// 生成唯一ID, 等待号段时响应 ctx 的取消与超时
+func (g *Generator) GenerateContext(ctx context.Context) (snowflake.ID, error) {
+	g.mu.Lock()
+	for {
+		if g.cur.value < g.cur.End {
+			id := g.cur.value
+			g.cur.value++
+
+			remain := float64(g.cur.End-g.cur.value) / float64(g.cur.End-g.cur.Start)
+			if g.next == nil && g.loading == nil && remain < g.prefetch {
+				g.load()
+			}
+			g.mu.Unlock()
+			return snowflake.ID(id), nil
+		}
+
+		// 切换到预取的号段
+		if g.next != nil {
+			g.cur, g.next = *g.next, nil
+			continue
+		}
+
+		// 预取失败时返回错误, 下次调用重新租用
+		if g.err != nil {
+			err := g.err
+			g.err = nil
+			g.mu.Unlock()
+			return -1, err
+		}
+
+		if g.loading == nil {
+			g.load()
+		}
+		done := g.loading
+		g.mu.Unlock()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		}
+		g.mu.Lock()
+	}
+}
+
+// 在后台租用下一个号段, 调用者需要持有 g.mu
+func (g *Generator) load() {
+	done := make(chan struct{})
+	g.loading = done
+	g.err = nil
+
+	go func() {
+		r, err := allocate(context.Background(), g.alloc, g.tag)
+
+		g.mu.Lock()
+		if err != nil {
+			g.err = err
+		} else {
+			g.next = &segment{value: r.Start, Range: r}
+		}
+		g.loading = nil
+		g.mu.Unlock()
+		close(done)
+	}()
+}
+
+// 业务标识
+func (g *Generator) Tag() string {
+	return g.tag
+}
+
+func allocate(ctx context.Context, alloc Allocator, tag string) (Range, error) {
+	r, err := alloc.Allocate(ctx, tag)
+	if err != nil {
+		return r, err
+	}
+	if r.Start < 0 || r.End <= r.Start {
+		return r, fmt.Errorf("%w: [%d, %d)", ErrInvalidRange, r.Start, r.End)
+	}
+	return r, nil
+}
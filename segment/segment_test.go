@@ -0,0 +1,140 @@
+package segment
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 依次返回长度为 size 的号段, fail 非 nil 时返回该错误, block 非 nil 时先等待它被关闭
+type fakeAllocator struct {
+	mu    sync.Mutex
+	size  int64
+	next  int64
+	calls int
+	fail  error
+	block chan struct{}
+}
+
+func (a *fakeAllocator) allocate(ctx context.Context, tag string) (Range, error) {
+	a.mu.Lock()
+	a.calls++
+	fail, block := a.fail, a.block
+	a.mu.Unlock()
+
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return Range{}, ctx.Err()
+		}
+	}
+	if fail != nil {
+		return Range{}, fail
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r := Range{Start: a.next, End: a.next + a.size}
+	a.next = r.End
+	return r, nil
+}
+
+func (a *fakeAllocator) set(fail error, block chan struct{}) {
+	a.mu.Lock()
+	a.fail, a.block = fail, block
+	a.mu.Unlock()
+}
+
+// 号段用尽时切换到预取的号段, 生成的ID连续递增
+func TestGeneratorSwitch(t *testing.T) {
+	a := &fakeAllocator{size: 10}
+	g, err := New(context.Background(), AllocatorFunc(a.allocate), "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for want := int64(0); want < 35; want++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(id) != want {
+			t.Fatalf("got %d, want %d", id, want)
+		}
+	}
+	a.mu.Lock()
+	calls := a.calls
+	a.mu.Unlock()
+	if calls < 4 {
+		t.Errorf("got %d allocations for 35 IDs in segments of 10, want at least 4", calls)
+	}
+}
+
+// 预取失败时号段用尽之后返回错误, 下一次调用重新租用
+func TestGeneratorPrefetchFailure(t *testing.T) {
+	a := &fakeAllocator{size: 10}
+	g, err := New(context.Background(), AllocatorFunc(a.allocate), "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cause := errors.New("database unavailable")
+	a.set(cause, nil)
+	for i := 0; i < 10; i++ {
+		if _, err := g.Generate(); err != nil {
+			t.Fatalf("ID %d of the current segment: %v", i, err)
+		}
+	}
+	if id, err := g.Generate(); !errors.Is(err, cause) || id != -1 {
+		t.Fatalf("segment exhausted: got (%d, %v), want (-1, %v)", id, err, cause)
+	}
+
+	a.set(nil, nil)
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id < 10 {
+		t.Errorf("after recovery: got %d, want an ID from a new segment", id)
+	}
+}
+
+// 等待号段时响应 ctx 的取消
+func TestGeneratorContext(t *testing.T) {
+	a := &fakeAllocator{size: 10}
+	g, err := New(context.Background(), AllocatorFunc(a.allocate), "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := make(chan struct{})
+	a.set(nil, block)
+	for i := 0; i < 10; i++ {
+		if _, err := g.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.GenerateContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+
+	close(block)
+	if id, err := g.Generate(); err != nil || id != 10 {
+		t.Errorf("after the allocator returned: got (%d, %v), want 10", id, err)
+	}
+}
+
+func TestNewInvalidRange(t *testing.T) {
+	alloc := AllocatorFunc(func(context.Context, string) (Range, error) {
+		return Range{Start: 5, End: 5}, nil
+	})
+	if _, err := New(context.Background(), alloc, "order"); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("got %v, want ErrInvalidRange", err)
+	}
+}
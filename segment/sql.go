@@ -0,0 +1,73 @@
+package segment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// 号段表的结构, 每个业务标识一行, max_id 为已经分配出去的最大值, step 为每次租用的号段长度
+// 例如插入 ('order', 1, 1000) 之后第一次租用 [1, 1001)
+const Schema = `CREATE TABLE leaf_alloc (
+	biz_tag     VARCHAR(128) NOT NULL PRIMARY KEY,
+	max_id      BIGINT       NOT NULL DEFAULT 1,
+	step        INT          NOT NULL,
+	update_time TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// 基于数据库号段表的 Allocator, 通过事务中的 UPDATE max_id = max_id + step 租用号段
+type SQLAllocator struct {
+	DB *sql.DB
+
+	// 号段表名, 为空时为 leaf_alloc
+	Table string
+
+	// 为 true 时使用 $1, $2 形式的占位符(PostgreSQL), 否则使用 ?
+	Dollar bool
+}
+
+// 租用号段 [max_id, max_id + step)
+func (a *SQLAllocator) Allocate(ctx context.Context, tag string) (Range, error) {
+	table := a.Table
+	if table == "" {
+		table = "leaf_alloc"
+	}
+
+	tx, err := a.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Range{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE "+table+" SET max_id = max_id + step, update_time = CURRENT_TIMESTAMP WHERE biz_tag = "+a.placeholder(1), tag)
+	if err != nil {
+		return Range{}, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return Range{}, fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+	}
+
+	var max, step int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT max_id, step FROM "+table+" WHERE biz_tag = "+a.placeholder(1), tag).Scan(&max, &step)
+	if err == sql.ErrNoRows {
+		return Range{}, fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+	}
+	if err != nil {
+		return Range{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Range{}, err
+	}
+	return Range{Start: max - step, End: max}, nil
+}
+
+func (a *SQLAllocator) placeholder(i int) string {
+	if a.Dollar {
+		return "$" + strconv.Itoa(i)
+	}
+	return "?"
+}
@@ -0,0 +1,154 @@
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 由多个子Node组成的Node, 用于极高并发的场景
+// 每个子Node占用自增序列的高位作为分片号, 调用时尽量路由到当前P缓存的子Node以避免跨核竞争
+//
+// 容量权衡: 分片数为 k 时每毫秒的总容量不变, 但单个子Node每毫秒只能生成 2^StepBits / k 个ID,
+// 单个goroutine突发生成时会更早等待下一毫秒; 同一毫秒内不同子Node生成的ID之间不保证按生成顺序递增
+type ShardedNode struct {
+	shards    []*Node
+	shardBits uint8
+	pool      sync.Pool
+	next      uint32
+
+	// 所有子Node共用的租约, 由 ShardedNode 释放
+	lease     MachineLease
+	closeOnce sync.Once
+
+	layout
+}
+
+// 返回一个包含 shards 个子Node的 ShardedNode, shards 必须是2的幂且不超过 2^StepBits
+func NewShardedNode(machineID int64, shards int, opts ...Option) (*ShardedNode, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var bits uint8
+	for 1<<bits < shards {
+		bits++
+	}
+	if c.Layout.MachineLast {
+		return nil, fmt.Errorf("%w: ShardedNode does not support MachineLast", ErrInvalidLayout)
+	}
+	if shards <= 0 || 1<<bits != shards || bits > c.Layout.StepBits {
+		return nil, fmt.Errorf("%w: shards must be a power of two between 1 and %d", ErrInvalidLayout, 1<<c.Layout.StepBits)
+	}
+
+	s := &ShardedNode{
+		shards:    make([]*Node, shards),
+		shardBits: bits,
+		layout:    newLayout(c.Layout),
+	}
+	if machineID < 0 || machineID > s.machineMax {
+		return nil, fmt.Errorf("%w: MachineID must be between 0 and %d", ErrMachineIDOutOfRange, s.machineMax)
+	}
+
+	// 分片号放在机器节点之后, 按照原配置解析时位于自增序列的高位
+	sc := c
+	sc.Layout.TimeBits = uint8(c.Layout.timeBits())
+	sc.Layout.MachineBits += bits
+	sc.Layout.StepBits -= bits
+
+	// 子Node看到的租约不实现 io.Closer, 不会各自释放; 只有第一个子Node调用 OnLeaseLost
+	if c.Lease != nil {
+		s.lease = c.Lease
+		sc.Lease = sharedLease{c.Lease}
+	}
+	for i := range s.shards {
+		if i == 1 {
+			sc.OnLeaseLost = nil
+		}
+		node, err := NewNode(machineID<<bits|int64(i), WithConfig(sc))
+		if err != nil {
+			return nil, err
+		}
+		s.shards[i] = node
+	}
+
+	// sync.Pool 按P缓存对象, 借此把调用路由到当前P使用过的子Node
+	s.pool.New = func() interface{} {
+		return s.shards[atomic.AddUint32(&s.next, 1)%uint32(len(s.shards))]
+	}
+
+	return s, nil
+}
+
+// 生成唯一ID, 参考 Node.Generate
+func (s *ShardedNode) Generate() ID {
+	node := s.pool.Get().(*Node)
+	id := node.Generate()
+	s.pool.Put(node)
+	return id
+}
+
+// 生成唯一ID, 不等待, 参考 Node.GenerateErr
+func (s *ShardedNode) GenerateErr() (ID, error) {
+	node := s.pool.Get().(*Node)
+	id, err := node.GenerateErr()
+	s.pool.Put(node)
+	return id, err
+}
+
+// 尝试生成唯一ID, 从不等待, 参考 Node.TryGenerate
+func (s *ShardedNode) TryGenerate() (ID, bool) {
+	node := s.pool.Get().(*Node)
+	id, ok := node.TryGenerate()
+	s.pool.Put(node)
+	return id, ok
+}
+
+// 生成唯一ID, 等待时响应 ctx 的取消与超时, 参考 Node.GenerateContext
+func (s *ShardedNode) GenerateContext(ctx context.Context) (ID, error) {
+	node := s.pool.Get().(*Node)
+	id, err := node.GenerateContext(ctx)
+	s.pool.Put(node)
+	return id, err
+}
+
+// 生成唯一ID, 最多等待 d, 参考 Node.GenerateTimeout
+func (s *ShardedNode) GenerateTimeout(d time.Duration) (ID, error) {
+	node := s.pool.Get().(*Node)
+	id, err := node.GenerateTimeout(d)
+	s.pool.Put(node)
+	return id, err
+}
+
+// 分片数
+func (s *ShardedNode) Shards() int {
+	return len(s.shards)
+}
+
+// 解析ID中的分片号
+func (s *ShardedNode) Shard(id ID) int64 {
+	return s.layout.step(id) >> (s.stepBits - s.shardBits)
+}
+
+// 使用的位数划分与起始时间
+func (s *ShardedNode) Layout() Layout {
+	return s.layout.spec
+}
+
+// 按照原配置解析ID中的时间戳, 单位与 Layout.Unit 相同
+func (s *ShardedNode) Time(id ID) int64 {
+	return s.layout.time(id)
+}
+
+// 按照原配置解析ID中的机器节点
+func (s *ShardedNode) Machine(id ID) int64 {
+	return s.layout.machine(id)
+}
+
+// 按照原配置解析ID中的自增序列, 高位为分片号
+func (s *ShardedNode) Step(id ID) int64 {
+	return s.layout.step(id)
+}
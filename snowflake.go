@@ -2,37 +2,112 @@
 package snowflake
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
+	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	// twitter snowflake epoch, 时间戳起始时间, 单位: 毫秒(ms)
-	// 缺省值: 2019-03-22T18:30:00 +0800
+	// 缺省值: DefaultEpoch(2019-03-22T18:30:00 +0800), 其他预设请参考 TwitterEpoch 等
 	// 开始使用之后不要再修改，否则会导致ID重复
 	// snowflake time = time.Now().UnixNano() / 1e6 - Epoch
-	Epoch int64 = 1553248800000
+	Epoch int64 = DefaultEpoch
 
 	// 定义机器节点使用的位数
-	// Machine + Step == 22
+	// 时间戳使用剩余的位数, 缺省为 63 - 10 - 12 = 41
 	MachineBits uint8 = 10
 
 	// 定义自增ID使用的位数
-	// Machine + Step == 22
+	// 时间戳使用剩余的位数, 缺省为 63 - 10 - 12 = 41
 	StepBits uint8 = 12
 
-	machineMax   int64
-	machineMask  int64
-	stepMask     int64
-	timeShift    uint8
-	machineShift uint8
+	// 机器节点中作为数据中心(datacenter)使用的高位位数, 其余低位为 worker
+	// 缺省值与 Twitter 原始实现的 5/5 划分相同
+	// Region + Datacenter <= Machine
+	DatacenterBits uint8 = 5
+
+	// 机器节点中作为区域(region)使用的最高位位数, 缺省不划分区域
+	// Region + Datacenter <= Machine
+	RegionBits uint8 = 0
+
+	// 定义租户使用的位数, 缺省不使用, 时间戳使用的位数相应减少
+	TenantBits uint8 = 0
 )
 
+// Node 配置, 每个Node持有独立的起始时间与位数划分
+type Config struct {
+	// ID的位数划分与起始时间
+	// 未通过 Option 指定时使用包级变量 Epoch, MachineBits, StepBits, DatacenterBits, RegionBits, TenantBits
+	Layout Layout
+
+	// 时钟源, 为nil时使用系统时钟
+	Clock Clock
+
+	// 机器时间回退时的处理策略
+	Rollback RollbackPolicy
+
+	// 允许的最大回退时间, 超过时不再按照 Rollback 处理, 直接返回 ErrClockMovedBackwards
+	// 为0时不限制
+	MaxBackwardDrift time.Duration
+
+	// 等待的预计剩余时间不超过该值时自旋, 否则休眠
+	// 为0时总是休眠, 设置为 time.Millisecond 可以在序列耗尽时自旋以降低延迟
+	SpinThreshold time.Duration
+
+	// 突发模式可以借用的未来时间, 为0时不借用
+	// 当前时间单位的自增序列耗尽时, 逻辑时钟最多超前实际时间 BorrowBudget 继续生成ID而不等待
+	BorrowBudget time.Duration
+
+	// 时间戳剩余可用时间不足 OverflowWarning 时调用一次 OnOverflowWarning, 参数为剩余时间
+	// 在新的goroutine中调用, 为0或 OnOverflowWarning 为nil时不检查
+	OverflowWarning   time.Duration
+	OnOverflowWarning func(remaining time.Duration)
+
+	// 机器节点ID的租约, 例如 machineid.Lease, 租约失效时调用 OnLeaseLost
+	Lease       MachineLease
+	OnLeaseLost func(err error)
+
+	// 租约失效之后停止生成ID, Generate 等方法返回 ErrLeaseLost, 以免与接手该ID的进程重复
+	Fencing bool
+}
+
+// 返回由包级变量组成的缺省配置
+func DefaultConfig() Config {
+	return Config{
+		Layout: DefaultLayout(),
+	}
+}
+
+// 在缺省配置的基础上应用 opts 并校验, 同时冻结包级变量
+func newConfig(opts []Option) (Config, error) {
+	Freeze()
+	if err := checkFrozen(); err != nil {
+		return Config{}, err
+	}
+
+	c := DefaultConfig()
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if err := c.Layout.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// 等待时单次休眠的最短时间
+const minPark = 100 * time.Microsecond
+
 // 编解码预设字符集, Base58与Base62 编码之后字符串依然可以保持字典序
 // Base32基于z-base-32
 const encodeBase32Map = "ybndrfg8ejkmcpqxot1uwisza345h769"
@@ -47,18 +122,6 @@ const encodeBase62Map = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrs
 
 var decodeBase62Map [128]byte
 
-var (
-	ErrInvalidBase62 = errors.New("invalid base62")
-	ErrInvalidBase58 = errors.New("invalid base58")
-	ErrInvalidBase32 = errors.New("invalid base32")
-)
-
-type JSONSyntaxError struct{ original []byte }
-
-func (j JSONSyntaxError) Error() string {
-	return fmt.Sprintf("invalid snowflake ID %q", string(j.original))
-}
-
 // 为编解码预先初始化好map
 func init() {
 	for i := 0; i < 128; i++ {
@@ -82,96 +145,484 @@ func init() {
 
 // snowflake Node
 type Node struct {
-	mu      sync.Mutex
-	time    int64
+	// 上次生成ID的时间与自增序列, 布局与ID去掉机器节点之后相同
+	// 通过 CAS 更新, 需要保证在32位平台上8字节对齐, 必须是第一个字段
+	state int64
+
+	// 突发模式借用的时间单位数量, 需要8字节对齐
+	borrowed uint64
+
+	// 机器节点ID, 可以通过 SetMachineID 替换, 需要8字节对齐
 	machine int64
-	step    int64
+
+	clock    Clock
+	rollback RollbackPolicy
+	maxDrift int64
+	budget   int64
+
+	spinThreshold time.Duration
+
+	// 达到 warnAt 时调用一次 onWarn
+	warnAt int64
+	onWarn func(remaining time.Duration)
+	warned int32
+
+	// 机器节点ID的租约, fenced 在租约失效之后关闭
+	lease  MachineLease
+	fenced <-chan struct{}
+
+	// Close 之后 closed 为1, done 被关闭
+	closed    int32
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// EntropyBits 大于0且 StepBits 为0时用于随机数去重
+	ent *entropySet
+
+	// 创建时间与 GenerateAt 上次使用的时间戳和自增序列
+	start    int64
+	histMu   sync.Mutex
+	histLast int64
+	histStep int64
+
+	// 由 NodePool 创建, 被淘汰之后 evicted 为1, 转而使用池中 poolID 对应的 Node
+	pool    *NodePool
+	poolID  int64
+	evicted int32
+
+	layout
 }
 
 // snowflake ID
 type ID int64
 
 // 返回一个新的snowflake Node
-func NewNode(machineID func() (int64, error)) (*Node, error) {
+// 未通过 Option 指定的配置项使用包级变量 Epoch, MachineBits, StepBits
+// 第一次调用之后包级变量被冻结, 参考 Freeze
+func NewNode(machineID int64, opts ...Option) (*Node, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	node := new(Node)
+	node.layout = newLayout(c.Layout)
 
-	if machineID, err := machineID(); err != nil {
-		return nil, err
-	} else {
-		node.machine = machineID
+	// 在启动租约监听和调用回调之前检查, 返回错误时不会留下 goroutine
+	if machineID < 0 || machineID > node.machineMax {
+		return nil, fmt.Errorf("%w: MachineID must be between 0 and %d", ErrMachineIDOutOfRange, node.machineMax)
+	}
+	node.machine = machineID
+	node.done = make(chan struct{})
+
+	node.rollback = c.Rollback
+	node.maxDrift = int64(c.MaxBackwardDrift / node.unit)
+	node.budget = int64(c.BorrowBudget / node.unit)
+	node.spinThreshold = c.SpinThreshold
+	node.clock = c.Clock
+	if node.clock == nil {
+		node.clock = systemClock{unit: node.unit}
+	}
+
+	if node.entropyBits > 0 && node.stepBits == 0 {
+		node.ent = new(entropySet)
 	}
 
-	machineMax = 1<<MachineBits - 1
-	machineMask = machineMax << StepBits
-	stepMask = 1<<StepBits - 1
-	timeShift = MachineBits + StepBits
-	machineShift = StepBits
+	// 实时生成的ID时间不早于创建时间的前一个时间单位, 之前的时间留给 GenerateAt
+	node.start = node.clock.Now()
+	node.state = (node.start - 1 - node.epoch) << node.stepBits
+	node.histLast, node.histStep = node.epoch-1, node.stepMask
+	if node.start < node.epoch || node.start-node.epoch > node.timeMask {
+		return nil, fmt.Errorf("%w: current time is out of the layout range", ErrTimestampOverflow)
+	}
+
+	if c.Lease != nil {
+		node.lease = c.Lease
+		if c.Fencing {
+			node.fenced = c.Lease.Lost()
+		}
+		go node.watchLease(c.OnLeaseLost)
+	}
 
-	if node.machine < 0 || node.machine > machineMask {
-		return nil, errors.New("MachineID must be between 0 and " + strconv.FormatInt(machineMax, 10))
+	if c.OverflowWarning > 0 && c.OnOverflowWarning != nil {
+		node.onWarn = c.OnOverflowWarning
+		node.warnAt = node.epoch + node.timeMask - int64(c.OverflowWarning/node.unit)
+		node.checkOverflow(node.start)
 	}
 
 	return node, nil
 }
 
 // 生成唯一ID
+// 当前毫秒的自增序列耗尽或机器时间回退时一直等待, 不受 RollbackPolicy 与 MaxBackwardDrift 影响
+// 无法通过等待恢复时返回 -1, 例如 Node 已关闭, 租约失效或时间戳用尽, 需要错误原因请使用 GenerateContext
 func (n *Node) Generate() ID {
-	n.mu.Lock()
+	id, err := n.generate(context.Background(), waitAlways, 0)
+	if err != nil {
+		return -1
+	}
+	return id
+}
+
+// 生成唯一ID, 不等待
+// 当前毫秒的自增序列耗尽时返回 ErrSequenceExhausted
+// 机器时间回退时除 BorrowTime 策略外均返回 ErrClockBackwards
+// 回退超过 MaxBackwardDrift 时返回 ErrClockMovedBackwards
+func (n *Node) GenerateErr() (ID, error) {
+	return n.generate(context.Background(), noWait, 0)
+}
+
+// 生成无符号的唯一ID, 用于 Layout.Unsigned 为 true 的布局, 其余与 Generate 相同
+// 无法生成时返回 UnsignedID(-1), 即 math.MaxUint64
+func (n *Node) GenerateUnsigned() UnsignedID {
+	return UnsignedID(n.Generate())
+}
+
+// 尝试生成唯一ID, 从不等待
+// 当前毫秒的自增序列耗尽或机器时间回退时返回 false, 适合宁可拒绝也不排队的限流场景
+func (n *Node) TryGenerate() (ID, bool) {
+	id, _, _, err := n.next(0)
+	if err == ErrNodeClosed {
+		if next, ok := n.successor(); ok {
+			return next.TryGenerate()
+		}
+	}
+	return id, err == nil
+}
+
+// 生成唯一ID, 等待序列耗尽或时钟回退时响应 ctx 的取消与超时
+// 等待被中断时返回 ctx.Err()
+func (n *Node) GenerateContext(ctx context.Context) (ID, error) {
+	return n.generate(ctx, waitPolicy, 0)
+}
+
+// 为租户生成唯一ID, 租户位于时间戳之后, 位数由 Layout.TenantBits 指定
+// 所有租户共用自增序列, 等待与错误处理与 GenerateContext 相同
+func (n *Node) GenerateForTenant(tenant int64) (ID, error) {
+	if tenant < 0 || tenant > n.tenantMax {
+		return -1, fmt.Errorf("%w: tenant must be between 0 and %d", ErrTenantOutOfRange, n.tenantMax)
+	}
+	return n.generate(context.Background(), waitPolicy, tenant)
+}
+
+// 生成唯一ID, 最多等待 d, 超时返回 context.DeadlineExceeded
+func (n *Node) GenerateTimeout(d time.Duration) (ID, error) {
+	// 不需要等待时避免创建 context
+	if id, ok := n.TryGenerate(); ok {
+		return id, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return n.generate(ctx, waitPolicy, 0)
+}
+
+// generate 遇到需要等待的情况时的处理方式
+type waitMode uint8
+
+const (
+	// 直接返回错误
+	noWait waitMode = iota
+
+	// 自增序列耗尽时等待, 机器时间回退时按照 RollbackPolicy 与 MaxBackwardDrift 处理
+	waitPolicy
+
+	// 自增序列耗尽与机器时间回退时都等待, 与最初的 Generate 相同
+	waitAlways
+)
+
+func (n *Node) generate(ctx context.Context, wait waitMode, tenant int64) (ID, error) {
+	for {
+		id, last, retry, err := n.next(tenant)
+		if err == ErrNodeClosed {
+			if next, ok := n.successor(); ok {
+				return next.generate(ctx, wait, tenant)
+			}
+		}
+		if wait == waitAlways && err != nil && errors.Is(err, ErrClockBackwards) {
+			retry = true
+		}
+		if err == nil || wait == noWait || !retry {
+			return id, err
+		}
+
+		// 等待时不持有锁, 其他调用者可以各自响应取消
+		if err := n.waitAfter(ctx, last); err != nil {
+			return -1, err
+		}
+	}
+}
+
+// 尝试生成一次ID, 通过 CAS 更新 state, 不持有锁
+// 需要等待时 retry 为 true, 等待时钟超过 last 之后可以重试; 机器时间回退时 last 总是上次的时间
+func (n *Node) next(tenant int64) (id ID, last int64, retry bool, err error) {
+	if n.fenced != nil {
+		select {
+		case <-n.fenced:
+			// Shutdown 释放租约时同样会关闭 fenced
+			if atomic.LoadInt32(&n.closed) != 0 {
+				return -1, 0, false, ErrNodeClosed
+			}
+			return -1, 0, false, n.leaseErr()
+		default:
+		}
+	}
+
+	// 随机数去重需要与时间戳的更新保持一致
+	if n.ent != nil {
+		n.ent.mu.Lock()
+		defer n.ent.mu.Unlock()
+	}
+
+	for {
+		// 先读取机器节点ID, 与 SetMachineID 的顺序相反
+		machine := atomic.LoadInt64(&n.machine)
+		old := atomic.LoadInt64(&n.state)
+		last = old>>n.stepBits + n.epoch
+
+		// 在读取 state 之后检查, 与 Shutdown 的顺序相反
+		if atomic.LoadInt32(&n.closed) != 0 {
+			return -1, 0, false, ErrNodeClosed
+		}
+		step := old & n.stepMask
+
+		wall := n.clock.Now()
+		now := wall
+
+		// 突发模式下逻辑时钟在预算内超前实际时间时继续使用逻辑时钟
+		if ahead := last - now; ahead > 0 && ahead <= n.budget {
+			now = last
+		}
+
+		// 回退时间过大时一般是配置错误, 等待或借用时间都没有意义
+		if n.maxDrift > 0 && last-now > n.maxDrift {
+			return -1, last, false, fmt.Errorf("%w: %s", ErrClockMovedBackwards, time.Duration(last-now)*n.unit)
+		}
+
+		// 借用上次的时间戳, 自增序列耗尽之后再按序列耗尽处理
+		if last > now && n.rollback.mode == rollbackBorrow {
+			now = last
+		}
+
+		if last == now { // 当前时间与上次时间相同, step++
+			step = (step + 1) & n.stepMask
+
+			// step超出范围, 需要等待下一个时间单位
+			// 没有自增序列的熵模式由随机数区分同一时间单位内的ID
+			if step == 0 && n.ent == nil {
+				// 突发模式下借用下一个时间单位, 超出预算时等待实际时间追上
+				if last+1-wall > n.budget {
+					return -1, last - n.budget, true, ErrSequenceExhausted
+				}
+				now = last + 1
+			}
+		} else if last > now { // 如果机器时间回退, 例: 闰秒;时间同步
+			// 等待时间达到上次的时间, 防止ID重复
+			backwards := time.Duration(last-now) * n.unit
+			return -1, last, n.rollback.canWait(backwards), fmt.Errorf("%w by %s", ErrClockBackwards, backwards)
+		} else { // 当前时间与上次时间不同, step归零
+			step = 0
+		}
+
+		// 时间戳用尽之后继续生成会与最早的ID重复
+		if now-n.epoch > n.timeMask {
+			return -1, 0, false, ErrTimestampOverflow
+		}
+
+		// 记录此次生成时间, 失败说明被其他goroutine抢先, 重新读取
+		if atomic.CompareAndSwapInt64(&n.state, old, (now-n.epoch)<<n.stepBits|step) {
+			// 期间机器节点ID被替换, 时间戳可能晚于替换时的时间戳, 放弃这次的自增序列重新生成
+			if atomic.LoadInt64(&n.machine) != machine {
+				continue
+			}
+			if now > wall && now > last {
+				atomic.AddUint64(&n.borrowed, 1)
+			}
+			if now >= n.warnAt && n.onWarn != nil {
+				n.checkOverflow(now)
+			}
+
+			id = n.compose(now, machine, tenant, step)
+			if n.entropyBits == 0 {
+				return id, 0, false, nil
+			}
+
+			e, err := n.entropy(now)
+			if err != nil {
+				return -1, now, err == ErrSequenceExhausted, err
+			}
+			return id | ID(e), 0, false, nil
+		}
+	}
+}
 
-	// 纳秒时间戳转毫秒时间戳, 1e6 = int64(time.Millisecond)
-	now := time.Now().UnixNano() / 1e6
+// 达到预警时间之后调用一次 onWarn
+func (n *Node) checkOverflow(now int64) {
+	if now < n.warnAt || !atomic.CompareAndSwapInt32(&n.warned, 0, 1) {
+		return
+	}
+	remaining := time.Duration(n.epoch+n.timeMask-now) * n.unit
+	go n.onWarn(remaining)
+}
 
-	if n.time == now { // 当前时间与上次时间相同, step++
-		n.step = (n.step + 1) & stepMask
+// 等待直到时钟超过 last
+// 预计剩余时间不超过 spinThreshold 时让出CPU自旋, 否则休眠
+func (n *Node) waitAfter(ctx context.Context, last int64) error {
+	for {
+		now := n.clock.Now()
+		if now > last {
+			return nil
+		}
 
-		// step超出范围
-		if n.step == 0 {
-			// 等待1ms
-			for now <= n.time {
-				now = time.Now().UnixNano() / 1e6
+		// 剩余时间在 (last-now) 与 (last-now+1) 个时间单位之间
+		// 不足一次最短休眠时同样自旋, 例如微秒精度的时间单位
+		if remain := time.Duration(last-now+1) * n.unit; remain <= n.spinThreshold || remain < minPark {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
 			}
+			runtime.Gosched()
+			continue
 		}
-	} else if n.time > now { // 如果机器时间回退, 例: 闰秒;时间同步
-		// 等待时间达到上次的时间, 防止ID重复
-		for now <= n.time {
-			now = time.Now().UnixNano() / 1e6
+
+		d := time.Duration(last-now) * n.unit
+		if d < minPark {
+			d = minPark
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
 		}
-		n.step = 0
-	} else { // 当前时间与上次时间不同, step归零
-		n.step = 0
 	}
+}
+
+// Node 使用的位数划分与起始时间
+func (n *Node) Layout() Layout {
+	return n.layout.spec
+}
+
+// 按照Node的配置解析ID中的时间戳, 单位与 Layout.Unit 相同
+func (n *Node) Time(id ID) int64 {
+	return n.layout.time(id)
+}
+
+// 按照Node的配置解析ID中的机器节点
+func (n *Node) Machine(id ID) int64 {
+	return n.layout.machine(id)
+}
+
+// 按照Node的配置解析ID中的自增序列
+func (n *Node) Step(id ID) int64 {
+	return n.layout.step(id)
+}
+
+// 按照Node的配置解析ID中的租户
+func (n *Node) Tenant(id ID) int64 {
+	return n.layout.tenant(id)
+}
 
-	// 记录此次生成时间
-	n.time = now
+// 按照Node的配置解析ID中的区域
+func (n *Node) Region(id ID) int64 {
+	return n.layout.region(id)
+}
 
-	// 通过位移把数据放到指定位置
-	r := ID(
-		(now-Epoch)<<timeShift |
-			(n.machine << machineShift) |
-			(n.step),
-	)
+// 按照Node的配置解析ID中的数据中心
+func (n *Node) Datacenter(id ID) int64 {
+	return n.layout.datacenter(id)
+}
 
-	n.mu.Unlock()
-	return r
+// 按照Node的配置解析ID中的 worker
+func (n *Node) Worker(id ID) int64 {
+	return n.layout.worker(id)
 }
 
 func (f ID) Int64() int64 {
 	return int64(f)
 }
 
+// 按位转换为无符号ID
+func (f ID) Unsigned() UnsignedID {
+	return UnsignedID(f)
+}
+
 // ID转换为10进制字符串
 func (f ID) String() string {
 	return strconv.FormatInt(int64(f), 10)
 }
 
+// 解析10进制字符串, 与 String 互逆
+// 超出int64或者为负数时返回 ErrOverflow, Unsigned 布局的ID请使用 ParseUnsignedString
+func ParseString(s string) (ID, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return -1, fmt.Errorf("%w: snowflake ID %q", ErrOverflow, s)
+		}
+		return -1, err
+	}
+	return ParseInt64(i)
+}
+
+// 把int64转换为ID, 与 Int64 互逆, 符号位必须为0, 负数返回 ErrOverflow
+func ParseInt64(i int64) (ID, error) {
+	if i < 0 {
+		return -1, fmt.Errorf("%w: negative snowflake ID %d", ErrOverflow, i)
+	}
+	return ID(i), nil
+}
+
 func (f ID) Base2() string {
 	return strconv.FormatInt(int64(f), 2)
 }
 
+// 解析 Base2 编码的ID, 包含0和1以外的字符时返回 ErrInvalidBase2, 超出int64时返回 ErrOverflow
+func ParseBase2(s string) (ID, error) {
+	return parseIntBase(s, 2, ErrInvalidBase2)
+}
+
 func (f ID) Base36() string {
 	return strconv.FormatInt(int64(f), 36)
 }
 
+// 解析 Base36 编码的ID, 不区分大小写, 超出int64时返回 ErrOverflow
+func ParseBase36(s string) (ID, error) {
+	return parseIntBase(s, 36, ErrInvalidBase36)
+}
+
+// 按照 strconv 的格式解析 base 进制的非负整数, 与其他编码相同不接受符号, 负数的ID无法解析
+func parseIntBase(s string, base int, invalid error) (ID, error) {
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		return -1, invalid
+	}
+	i, err := strconv.ParseInt(s, base, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return -1, fmt.Errorf("%w: snowflake ID %q", ErrOverflow, s)
+		}
+		return -1, invalid
+	}
+	return ID(i), nil
+}
+
+// 编码为固定16个字符的小写16进制, 高位补0, 负数的ID按位视为无符号数, 便于与抓包和二进制日志对照
+func (f ID) Hex() string {
+	return string(appendHex(make([]byte, 0, 16), uint64(f)))
+}
+
+// 解析16进制, 与 Hex 互逆, 不区分大小写, 可以带 0x 前缀, 不足16个字符时视为省略了高位的0
+func ParseHex(s string) (ID, error) {
+	u, err := parseHex(s)
+	if err != nil {
+		return -1, err
+	}
+	return ID(u), nil
+}
+
 func (f ID) Base32() string {
 	if f < 32 {
 		return string(encodeBase32Map[f])
@@ -192,17 +643,21 @@ func (f ID) Base32() string {
 	return string(b)
 }
 
+// 空字符串或包含字母表以外的字符时返回 ErrInvalidBase32, 超出int64时返回 ErrOverflow
 func ParseBase32(b []byte) (ID, error) {
-	var id int64
+	return parseSigned(b, &decodeBase32Map, 32, ErrInvalidBase32)
+}
 
-	for i := range b {
-		if decodeBase32Map[b[i]] == 0xFF {
-			return -1, ErrInvalidBase32
-		}
-		id = id*32 + int64(decodeBase32Map[b[i]])
+// 使用 parseUintBase 解析, 超出int64时返回 ErrOverflow
+func parseSigned(b []byte, decode *[128]byte, base uint64, invalid error) (ID, error) {
+	u, err := parseUintBase(b, decode, base, invalid)
+	if err != nil {
+		return -1, err
 	}
-
-	return ID(id), nil
+	if u > math.MaxInt64 {
+		return -1, fmt.Errorf("%w: %q", ErrOverflow, b)
+	}
+	return ID(u), nil
 }
 
 func (f ID) Base58() string {
@@ -224,17 +679,9 @@ func (f ID) Base58() string {
 	return string(b)
 }
 
+// 错误与 ParseBase32 相同
 func ParseBase58(b []byte) (ID, error) {
-	var id int64
-
-	for i := range b {
-		if decodeBase58Map[b[i]] == 0xFF {
-			return -1, ErrInvalidBase58
-		}
-		id = id*58 + int64(decodeBase58Map[b[i]])
-	}
-
-	return ID(id), nil
+	return parseSigned(b, &decodeBase58Map, 58, ErrInvalidBase58)
 }
 
 func (f ID) Base62() string {
@@ -256,63 +703,208 @@ func (f ID) Base62() string {
 	return string(b)
 }
 
+// 错误与 ParseBase32 相同
 func ParseBase62(b []byte) (ID, error) {
-	var id int64
-
-	for i := range b {
-		if decodeBase62Map[b[i]] == 0xFF {
-			return -1, ErrInvalidBase62
-		}
-		id = id*62 + int64(decodeBase62Map[b[i]])
-	}
-
-	return ID(id), nil
-}
-
-func (f ID) Base64() string {
-	return base64.StdEncoding.EncodeToString(f.Bytes())
+	return parseSigned(b, &decodeBase62Map, 62, ErrInvalidBase62)
 }
 
 func (f ID) Bytes() []byte {
 	return []byte(f.String())
 }
 
+// 解析10进制ASCII字节, 与 Bytes 互逆, 错误与 ParseString 相同
+func ParseBytes(b []byte) (ID, error) {
+	return ParseString(string(b))
+}
+
 func (f ID) IntBytes() [8]byte {
 	var b [8]byte
 	binary.BigEndian.PutUint64(b[:], uint64(f))
 	return b
 }
 
+// 解析8字节大端序, 与 IntBytes 互逆
+func ParseIntBytes(b [8]byte) ID {
+	return ID(binary.BigEndian.Uint64(b[:]))
+}
+
+// 按照缺省配置解析ID中的时间戳, 单位: 毫秒(ms)
 func (f ID) Time() int64 {
-	return (int64(f) >> timeShift) + Epoch
+	return defaultLayout().time(f)
 }
 
+// 按照缺省配置解析ID中的机器节点
 func (f ID) Machine() int64 {
-	return int64(f) & machineMask >> machineShift
+	return defaultLayout().machine(f)
 }
 
+// 按照缺省配置解析ID中的自增序列
 func (f ID) Step() int64 {
-	return int64(f) & stepMask
+	return defaultLayout().step(f)
+}
+
+// 按照缺省配置解析ID中的租户
+func (f ID) Tenant() int64 {
+	return defaultLayout().tenant(f)
 }
 
+// 按照缺省配置解析ID中的区域
+func (f ID) Region() int64 {
+	return defaultLayout().region(f)
+}
+
+// 按照缺省配置解析ID中的数据中心
+func (f ID) Datacenter() int64 {
+	return defaultLayout().datacenter(f)
+}
+
+// 按照缺省配置解析ID中的 worker
+func (f ID) Worker() int64 {
+	return defaultLayout().worker(f)
+}
+
+// 为 true 时 MarshalJSON 编码为不带引号的数字, 并且 UnmarshalJSON 接受不带引号的数字
+// 缺省编码为带引号的10进制字符串, JavaScript 无法精确表示超过 2^53 的整数, 只在调用方都能处理 int64 时使用
+var JSONAsNumber = false
+
+// 为 true 时 UnmarshalJSON 只接受 MarshalJSON 输出的格式
+// 缺省同时接受带引号的字符串, 不带引号的整数与 null, null 解析为0
+var StrictJSON = false
+
+// JSON 字符串使用的编码
+type JSONEncoding uint8
+
+const (
+	// 10进制, 缺省编码
+	JSONDecimal JSONEncoding = iota
+
+	// Base58, 与 ID.Base58 相同, 适合在URL中使用的短ID
+	JSONBase58
+
+	// Base62, 与 ID.Base62 相同
+	JSONBase62
+)
+
+// MarshalJSON 输出字符串时使用的编码, UnmarshalJSON 按照相同的编码解析带引号的字符串
+// 不带引号的数字总是10进制, 开始使用之后不要修改, 否则无法解析之前输出的ID
+var JSONStringEncoding = JSONDecimal
+
 func (f ID) MarshalJSON() ([]byte, error) {
-	buff := make([]byte, 0, 22)
-	buff = append(buff, '"')
-	buff = strconv.AppendInt(buff, int64(f), 10)
-	buff = append(buff, '"')
-	return buff, nil
+	return f.appendJSON(make([]byte, 0, 22)), nil
+}
+
+// 把 MarshalJSON 的结果追加到 b
+func (f ID) appendJSON(b []byte) []byte {
+	if JSONAsNumber {
+		return strconv.AppendInt(b, int64(f), 10)
+	}
+	if JSONStringEncoding != JSONDecimal {
+		return appendJSONString(b, uint64(f))
+	}
+
+	b = append(b, '"')
+	b = strconv.AppendInt(b, int64(f), 10)
+	return append(b, '"')
 }
 
 func (f *ID) UnmarshalJSON(b []byte) error {
-	if len(b) < 3 || b[0] != '"' || b[len(b)-1] != '"' {
-		return JSONSyntaxError{b}
+	text, encoded, err := jsonText(b)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*f = 0
+		return nil
 	}
+	if encoded {
+		u, err := parseJSONString(text)
+		*f = ID(u)
+		return err
+	}
+	return f.UnmarshalText(text)
+}
+
+// 按照 JSONStringEncoding 编码为带引号的字符串并追加到 b, 负数的ID按位视为无符号数
+func appendJSONString(b []byte, v uint64) []byte {
+	alphabet := encodeBase58Map
+	if JSONStringEncoding == JSONBase62 {
+		alphabet = encodeBase62Map
+	}
+
+	b = append(b, '"')
+	b = appendUintBase(b, v, alphabet)
+	return append(b, '"')
+}
 
-	i, err := strconv.ParseInt(string(b[1:len(b)-1]), 10, 64)
+// 按照 JSONStringEncoding 解析去掉引号的字符串
+func parseJSONString(b []byte) (uint64, error) {
+	if JSONStringEncoding == JSONBase62 {
+		return parseUintBase(b, &decodeBase62Map, 62, ErrInvalidBase62)
+	}
+	return parseUintBase(b, &decodeBase58Map, 58, ErrInvalidBase58)
+}
+
+// 按照 StrictJSON 与 JSONAsNumber 取出JSON值中的文本, null 时返回nil
+// 带引号并且 JSONStringEncoding 不是10进制时 encoded 为 true, 否则返回的文本为10进制数字
+func jsonText(b []byte) (text []byte, encoded bool, err error) {
+	quoted := len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"'
+	switch {
+	case quoted:
+		if StrictJSON && JSONAsNumber {
+			return nil, false, JSONSyntaxError{b}
+		}
+		text := b[1 : len(b)-1]
+		if JSONStringEncoding != JSONDecimal {
+			return text, true, nil
+		}
+		if !isDecimal(string(text)) {
+			return nil, false, JSONSyntaxError{b}
+		}
+		return text, false, nil
+	case string(b) == "null":
+		if StrictJSON {
+			return nil, false, JSONSyntaxError{b}
+		}
+		return nil, false, nil
+	}
+
+	if StrictJSON && !JSONAsNumber || !isDecimal(string(b)) {
+		return nil, false, JSONSyntaxError{b}
+	}
+	return b, false, nil
+}
+
+// 编码为10进制文本, 用于 JSON 的 map 键以及 YAML, TOML 等支持 encoding.TextMarshaler 的库
+func (f ID) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(make([]byte, 0, 20), int64(f), 10), nil
+}
+
+// 解析10进制文本
+func (f *ID) UnmarshalText(b []byte) error {
+	i, err := strconv.ParseInt(string(b), 10, 64)
 	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("%w: snowflake ID %s", ErrOverflow, b)
+		}
 		return err
 	}
 
 	*f = ID(i)
 	return nil
 }
+
+// 编码为8字节大端序, 与 IntBytes 相同, 用于 gob, go-redis 等支持 encoding.BinaryMarshaler 的库
+func (f ID) MarshalBinary() ([]byte, error) {
+	b := f.IntBytes()
+	return b[:], nil
+}
+
+// 解析8字节大端序, 长度不是8时返回 ErrInvalidBinary
+func (f *ID) UnmarshalBinary(b []byte) error {
+	if len(b) != 8 {
+		return fmt.Errorf("%w: length %d", ErrInvalidBinary, len(b))
+	}
+
+	*f = ID(binary.BigEndian.Uint64(b))
+	return nil
+}
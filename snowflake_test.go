@@ -0,0 +1,384 @@
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 并发生成的ID互不重复, 每个goroutine 内严格递增
+func TestGenerateConcurrent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"wide step", []Option{WithLayout(Layout{StepBits: 22, Epoch: Epoch})}},
+		// 自增序列很快耗尽, 覆盖等待下一个时间单位的路径
+		{"small step", []Option{WithStepBits(4)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := NewNode(0, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer node.Close()
+
+			ids := generateConcurrent(t, 16, 500, node.Generate)
+			checkUnique(t, ids)
+		})
+	}
+}
+
+// 替换机器节点ID期间生成的ID互不重复
+func TestGenerateConcurrentSetMachineID(t *testing.T) {
+	node, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	stop := make(chan struct{})
+	swapped := make(chan struct{})
+	go func() {
+		defer close(swapped)
+		for i := int64(0); ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := node.SetMachineID(1 + i%2); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	ids := generateConcurrent(t, 16, 2000, node.Generate)
+	close(stop)
+	<-swapped
+	checkUnique(t, ids)
+}
+
+// 边界值与非法输入, 解析失败时返回 -1
+func TestParseBase(t *testing.T) {
+	bases := []struct {
+		name     string
+		alphabet string
+		encode   func(ID) string
+		uencode  func(UnsignedID) string
+		parse    func([]byte) (ID, error)
+		invalid  error
+	}{
+		{"base32", encodeBase32Map, ID.Base32, UnsignedID.Base32, ParseBase32, ErrInvalidBase32},
+		{"base58", encodeBase58Map, ID.Base58, UnsignedID.Base58, ParseBase58, ErrInvalidBase58},
+		{"base62", encodeBase62Map, ID.Base62, UnsignedID.Base62, ParseBase62, ErrInvalidBase62},
+	}
+
+	for _, b := range bases {
+		t.Run(b.name, func(t *testing.T) {
+			for _, id := range []ID{0, 1, ID(len(b.alphabet)), math.MaxInt64} {
+				got, err := b.parse([]byte(b.encode(id)))
+				if err != nil || got != id {
+					t.Errorf("round trip %d: got (%d, %v)", id, got, err)
+				}
+			}
+
+			tests := []struct {
+				name string
+				in   string
+				want error
+			}{
+				{"empty", "", b.invalid},
+				{"negative", "-" + b.encode(1), b.invalid},
+				{"invalid character", b.encode(1) + "!", b.invalid},
+				{"non-ASCII", b.encode(1) + "\u00e9", b.invalid},
+				{"max int64 + 1", b.uencode(math.MaxInt64 + 1), ErrOverflow},
+				{"max uint64", b.uencode(math.MaxUint64), ErrOverflow},
+				{"beyond uint64", b.uencode(math.MaxUint64) + b.alphabet[:1], ErrOverflow},
+			}
+			for _, tt := range tests {
+				got, err := b.parse([]byte(tt.in))
+				if !errors.Is(err, tt.want) || got != -1 {
+					t.Errorf("%s %q: got (%d, %v), want (-1, %v)", tt.name, tt.in, got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// Base2 与 Base36 不接受符号, 与其他编码相同
+func TestParseIntBase(t *testing.T) {
+	bases := []struct {
+		name    string
+		encode  func(ID) string
+		parse   func(string) (ID, error)
+		invalid error
+
+		// MaxInt64 + 1
+		overflow string
+	}{
+		{"base2", ID.Base2, ParseBase2, ErrInvalidBase2, "1" + strings.Repeat("0", 63)},
+		{"base36", ID.Base36, ParseBase36, ErrInvalidBase36, "1y2p0ij32e8e8"},
+	}
+
+	for _, b := range bases {
+		t.Run(b.name, func(t *testing.T) {
+			for _, id := range []ID{0, 1, 36, math.MaxInt64} {
+				got, err := b.parse(b.encode(id))
+				if err != nil || got != id {
+					t.Errorf("round trip %d: got (%d, %v)", id, got, err)
+				}
+			}
+
+			tests := []struct {
+				name string
+				in   string
+				want error
+			}{
+				{"empty", "", b.invalid},
+				{"negative", "-101", b.invalid},
+				{"plus", "+101", b.invalid},
+				{"negative zero", "-0", b.invalid},
+				{"space", " 101", b.invalid},
+				{"underscore", "1_0", b.invalid},
+				{"max int64 + 1", b.overflow, ErrOverflow},
+			}
+			for _, tt := range tests {
+				got, err := b.parse(tt.in)
+				if !errors.Is(err, tt.want) || got != -1 {
+					t.Errorf("%s %q: got (%d, %v), want (-1, %v)", tt.name, tt.in, got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// 可以手动设置的时钟, 单位与 Layout.Unit 相同
+type manualClock struct {
+	now int64
+}
+
+func (c *manualClock) Now() int64 {
+	return atomic.LoadInt64(&c.now)
+}
+
+func (c *manualClock) Set(now int64) {
+	atomic.StoreInt64(&c.now, now)
+}
+
+// Generate 不受回退策略影响一直等待, 无法恢复时返回 -1 而不是 panic
+func TestGenerateNoPanic(t *testing.T) {
+	start := time.Now().UnixNano() / int64(time.Millisecond)
+	policies := []struct {
+		name string
+		opts []Option
+	}{
+		{"ReturnError", []Option{WithRollbackPolicy(ReturnError)}},
+		{"WaitFor", []Option{WithRollbackPolicy(WaitFor(time.Millisecond))}},
+		// 回退超过 MaxBackwardDrift, 例如 NTP 直接调整时间
+		{"MaxBackwardDrift", []Option{WithMaxBackwardDrift(time.Millisecond)}},
+	}
+	for _, p := range policies {
+		t.Run(p.name, func(t *testing.T) {
+			clock := &manualClock{now: start}
+			node, err := NewNode(1, append(p.opts, WithClock(clock))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer node.Close()
+
+			first := node.Generate()
+			clock.Set(start - 10)
+			if _, err := node.GenerateErr(); !errors.Is(err, ErrClockBackwards) {
+				t.Fatalf("GenerateErr: got %v, want ErrClockBackwards", err)
+			}
+
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				clock.Set(start + 1)
+			}()
+			if id := node.Generate(); id <= first {
+				t.Errorf("Generate after the clock moved back: got %d, want an ID after %d", id, first)
+			}
+		})
+	}
+
+	t.Run("overflow", func(t *testing.T) {
+		l := Layout{TimeBits: 20, MachineBits: 10, StepBits: 6, Epoch: Epoch}
+		clock := &manualClock{now: Epoch + 1<<20 - 1}
+		node, err := NewNode(1, WithLayout(l), WithClock(clock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer node.Close()
+
+		clock.Set(Epoch + 1<<20)
+		if id := node.Generate(); id != -1 {
+			t.Errorf("Generate after the timestamp overflowed: got %d, want -1", id)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		node, err := NewNode(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.Close()
+		if id := node.Generate(); id != -1 {
+			t.Errorf("Generate after Close: got %d, want -1", id)
+		}
+	})
+}
+
+// 启动 workers 个goroutine 各调用 n 次 generate, 检查每个goroutine 得到的ID严格递增
+func generateConcurrent(t *testing.T, workers, n int, generate func() ID) [][]ID {
+	t.Helper()
+
+	ids := make([][]ID, workers)
+	var wg sync.WaitGroup
+	for w := range ids {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			list := make([]ID, n)
+			for i := range list {
+				list[i] = generate()
+			}
+			ids[w] = list
+		}(w)
+	}
+	wg.Wait()
+
+	for w, list := range ids {
+		for i := 1; i < len(list); i++ {
+			if list[i] <= list[i-1] {
+				t.Fatalf("worker %d: ID %d at %d is not greater than %d", w, list[i], i, list[i-1])
+			}
+		}
+	}
+	return ids
+}
+
+func checkUnique(t *testing.T, ids [][]ID) {
+	t.Helper()
+
+	seen := make(map[ID]struct{})
+	for _, list := range ids {
+		for _, id := range list {
+			if _, ok := seen[id]; ok {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = struct{}{}
+		}
+	}
+}
+
+// 缺省布局每毫秒最多4096个ID, 吞吐量受自增序列限制, wide step 去掉这个限制以比较争用的开销
+var benchLayouts = []struct {
+	name string
+	opts []Option
+}{
+	{"default", nil},
+	{"wide step", []Option{WithLayout(Layout{StepBits: 22, Epoch: Epoch})}},
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for _, bl := range benchLayouts {
+		b.Run(bl.name, func(b *testing.B) {
+			node, err := NewNode(0, bl.opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer node.Close()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				node.Generate()
+			}
+		})
+	}
+}
+
+// 使用 -cpu 比较不同并发度下的吞吐量, 例如 go test -bench GenerateParallel -cpu 1,8,32,64
+func BenchmarkGenerateParallel(b *testing.B) {
+	for _, bl := range benchLayouts {
+		b.Run(bl.name, func(b *testing.B) {
+			node, err := NewNode(0, bl.opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer node.Close()
+
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					node.Generate()
+				}
+			})
+		})
+	}
+}
+
+// 固定长度的编码按字典序排序与按无符号数值排序相同
+func TestPaddedOrder(t *testing.T) {
+	encodings := []struct {
+		name   string
+		encode func(ID) string
+	}{
+		{"String", ID.PaddedString},
+		{"Crockford", ID.PaddedCrockford},
+		{"Base58", ID.PaddedBase58},
+		{"Base62", ID.PaddedBase62},
+	}
+	ids := []ID{0, 1, 57, 58, 61, 62, 1 << 32, math.MaxInt64 - 1, math.MaxInt64, -1 << 63, -1}
+	for _, e := range encodings {
+		t.Run(e.name, func(t *testing.T) {
+			prev := e.encode(ids[0])
+			for _, id := range ids[1:] {
+				s := e.encode(id)
+				if len(s) != len(prev) {
+					t.Fatalf("%d: got length %d, want %d", id, len(s), len(prev))
+				}
+				if s <= prev {
+					t.Fatalf("%d: %q is not greater than %q", id, s, prev)
+				}
+				prev = s
+			}
+		})
+	}
+}
+
+// 冻结之后修改包级变量, NewNode 返回 ErrConfigFrozen, 解析ID继续使用冻结时的配置
+func TestFreezeModified(t *testing.T) {
+	node, err := NewNode(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+	id := node.Generate()
+
+	old := MachineBits
+	MachineBits = old - 1
+	defer func() { MachineBits = old }()
+
+	if _, err := NewNode(1); !errors.Is(err, ErrConfigFrozen) {
+		t.Errorf("NewNode: got %v, want ErrConfigFrozen", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("ID accessor panicked: %v", r)
+		}
+	}()
+	if id.Machine() != 3 || id.Time() != node.Time(id) || id.Step() != node.Step(id) {
+		t.Errorf("got machine %d time %d step %d, want 3 %d %d", id.Machine(), id.Time(), id.Step(), node.Time(id), node.Step(id))
+	}
+}
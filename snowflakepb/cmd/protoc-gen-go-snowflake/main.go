@@ -0,0 +1,180 @@
+// protoc 插件, 为标注了 [(snowflake.id) = true] 的 int64 字段生成 snowflake.ID 类型的访问方法
+//
+//	protoc --go_out=. --go-snowflake_out=. order.proto
+//
+// 字段 user_id 生成 GetUserID 与 SetUserID, 字段 owner 生成 GetOwnerID 与 SetOwnerID,
+// 重复字段 item_ids 生成返回 []snowflake.ID 的 GetItemIDs 与 SetItemIDs, 与 protoc-gen-go 的输出位于同一个包
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ming913/snowflake/snowflakepb"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const snowflakePackage = protogen.GoImportPath("github.com/ming913/snowflake")
+
+func main() {
+	var flags flag.FlagSet
+	protogen.Options{ParamFunc: flags.Set}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		for _, f := range gen.Files {
+			if !f.Generate {
+				continue
+			}
+			if err := generateFile(gen, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// 标注的字段与生成的方法名
+type idField struct {
+	*protogen.Field
+	name string
+}
+
+func generateFile(gen *protogen.Plugin, f *protogen.File) error {
+	var messages []*protogen.Message
+	fields := make(map[*protogen.Message][]idField)
+	var walk func(ms []*protogen.Message) error
+	walk = func(ms []*protogen.Message) error {
+		for _, m := range ms {
+			if m.Desc.IsMapEntry() {
+				continue
+			}
+			list, err := collect(m)
+			if err != nil {
+				return err
+			}
+			if len(list) > 0 {
+				messages = append(messages, m)
+				fields[m] = list
+			}
+			if err := walk(m.Messages); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(f.Messages); err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_snowflake.pb.go", f.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-snowflake. DO NOT EDIT.")
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	g.P("package ", f.GoPackageName)
+	for _, m := range messages {
+		for _, fd := range fields[m] {
+			generateField(g, m, fd)
+		}
+	}
+	return nil
+}
+
+// 收集消息中标注的字段, 标注在其他类型的字段上或者方法名冲突时返回错误
+func collect(m *protogen.Message) ([]idField, error) {
+	used := make(map[string]bool)
+	for _, fd := range m.Fields {
+		used["Get"+fd.GoName] = true
+	}
+
+	var list []idField
+	for _, fd := range m.Fields {
+		if !snowflakepb.IsID(fd.Desc) {
+			continue
+		}
+		switch fd.Desc.Kind() {
+		case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		default:
+			return nil, fmt.Errorf("%s: (snowflake.id) requires a signed 64-bit integer field, got %s", fd.Desc.FullName(), fd.Desc.Kind())
+		}
+		if fd.Desc.IsMap() {
+			return nil, fmt.Errorf("%s: (snowflake.id) is not supported on map fields", fd.Desc.FullName())
+		}
+
+		name := accessorName(fd.GoName, fd.Desc.IsList())
+		if used["Get"+name] || used["Set"+name] {
+			return nil, fmt.Errorf("%s: generated accessor %s conflicts with another field", fd.Desc.FullName(), name)
+		}
+		used["Get"+name] = true
+		used["Set"+name] = true
+		list = append(list, idField{Field: fd, name: name})
+	}
+	return list, nil
+}
+
+// UserId 转换为 UserID, 其他名称末尾加上 ID, 重复字段 ItemIds 转换为 ItemIDs
+func accessorName(goName string, list bool) string {
+	suffix := "ID"
+	if list {
+		suffix = "IDs"
+	}
+	return strings.TrimSuffix(goName, suffix[:1]+strings.ToLower(suffix[1:])) + suffix
+}
+
+func generateField(g *protogen.GeneratedFile, m *protogen.Message, fd idField) {
+	id := g.QualifiedGoIdent(snowflakePackage.Ident("ID"))
+	recv := "x *" + m.GoIdent.GoName
+
+	if fd.Desc.IsList() {
+		g.P("// 以 []snowflake.ID 返回 ", fd.GoName, ", x 为nil或字段为空时返回nil")
+		g.P("func (", recv, ") Get", fd.name, "() []", id, " {")
+		g.P("v := x.Get", fd.GoName, "()")
+		g.P("if len(v) == 0 {")
+		g.P("return nil")
+		g.P("}")
+		g.P("ids := make([]", id, ", len(v))")
+		g.P("for i, n := range v {")
+		g.P("ids[i] = ", id, "(n)")
+		g.P("}")
+		g.P("return ids")
+		g.P("}")
+		g.P()
+		g.P("// 使用 []snowflake.ID 设置 ", fd.GoName)
+		g.P("func (", recv, ") Set", fd.name, "(ids []", id, ") {")
+		g.P("if ids == nil {")
+		g.P("x.", fd.GoName, " = nil")
+		g.P("return")
+		g.P("}")
+		g.P("v := make([]int64, len(ids))")
+		g.P("for i, n := range ids {")
+		g.P("v[i] = int64(n)")
+		g.P("}")
+		g.P("x.", fd.GoName, " = v")
+		g.P("}")
+		g.P()
+		return
+	}
+
+	g.P("// 以 snowflake.ID 返回 ", fd.GoName, ", x 为nil或字段未设置时返回0")
+	g.P("func (", recv, ") Get", fd.name, "() ", id, " {")
+	g.P("return ", id, "(x.Get", fd.GoName, "())")
+	g.P("}")
+	g.P()
+	g.P("// 使用 snowflake.ID 设置 ", fd.GoName)
+	g.P("func (", recv, ") Set", fd.name, "(id ", id, ") {")
+	switch {
+	case fd.Oneof != nil && !fd.Oneof.Desc.IsSynthetic():
+		g.P("x.", fd.Oneof.GoName, " = &", fd.GoIdent, "{", fd.GoName, ": int64(id)}")
+	case fd.Desc.HasPresence():
+		g.P("v := int64(id)")
+		g.P("x.", fd.GoName, " = &v")
+	default:
+		g.P("x.", fd.GoName, " = int64(id)")
+	}
+	g.P("}")
+	g.P()
+}
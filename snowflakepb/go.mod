@@ -0,0 +1,9 @@
+module github.com/ming913/snowflake/snowflakepb
+
+// google.golang.org/protobuf v1.36 要求 Go 1.22
+go 1.22
+
+require (
+	github.com/ming913/snowflake v0.1.0
+	google.golang.org/protobuf v1.36.6
+)
@@ -0,0 +1,152 @@
+// snowflake ID 的 protobuf 定义
+//
+// 跨服务的接口中可以使用 SnowflakeID 代替 int64, 或者在 int64 字段上标注
+// [(snowflake.id) = true], 由 protoc-gen-go-snowflake 生成 snowflake.ID 类型的访问方法
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: snowflake.proto
+
+package snowflakepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// snowflake ID, 与 snowflake.ID 相同使用 int64 保存
+type SnowflakeID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         int64                  `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnowflakeID) Reset() {
+	*x = SnowflakeID{}
+	mi := &file_snowflake_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnowflakeID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnowflakeID) ProtoMessage() {}
+
+func (x *SnowflakeID) ProtoReflect() protoreflect.Message {
+	mi := &file_snowflake_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnowflakeID.ProtoReflect.Descriptor instead.
+func (*SnowflakeID) Descriptor() ([]byte, []int) {
+	return file_snowflake_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SnowflakeID) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+var file_snowflake_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         50621,
+		Name:          "snowflake.id",
+		Tag:           "varint,50621,opt,name=id",
+		Filename:      "snowflake.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// 标注 int64 或 sint64, sfixed64 字段保存的是 snowflake ID
+	//
+	// optional bool id = 50621;
+	E_Id = &file_snowflake_proto_extTypes[0]
+)
+
+var File_snowflake_proto protoreflect.FileDescriptor
+
+const file_snowflake_proto_rawDesc = "" +
+	"\n" +
+	"\x0fsnowflake.proto\x12\tsnowflake\x1a google/protobuf/descriptor.proto\"#\n" +
+	"\vSnowflakeID\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x03R\x05value:/\n" +
+	"\x02id\x12\x1d.google.protobuf.FieldOptions\x18\xbd\x8b\x03 \x01(\bR\x02idB*Z(github.com/ming913/snowflake/snowflakepbb\x06proto3"
+
+var (
+	file_snowflake_proto_rawDescOnce sync.Once
+	file_snowflake_proto_rawDescData []byte
+)
+
+func file_snowflake_proto_rawDescGZIP() []byte {
+	file_snowflake_proto_rawDescOnce.Do(func() {
+		file_snowflake_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_snowflake_proto_rawDesc), len(file_snowflake_proto_rawDesc)))
+	})
+	return file_snowflake_proto_rawDescData
+}
+
+var file_snowflake_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_snowflake_proto_goTypes = []any{
+	(*SnowflakeID)(nil),               // 0: snowflake.SnowflakeID
+	(*descriptorpb.FieldOptions)(nil), // 1: google.protobuf.FieldOptions
+}
+var file_snowflake_proto_depIdxs = []int32{
+	1, // 0: snowflake.id:extendee -> google.protobuf.FieldOptions
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_snowflake_proto_init() }
+func file_snowflake_proto_init() {
+	if File_snowflake_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_snowflake_proto_rawDesc), len(file_snowflake_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_snowflake_proto_goTypes,
+		DependencyIndexes: file_snowflake_proto_depIdxs,
+		MessageInfos:      file_snowflake_proto_msgTypes,
+		ExtensionInfos:    file_snowflake_proto_extTypes,
+	}.Build()
+	File_snowflake_proto = out.File
+	file_snowflake_proto_goTypes = nil
+	file_snowflake_proto_depIdxs = nil
+}
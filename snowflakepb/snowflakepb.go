@@ -0,0 +1,43 @@
+// snowflake.ID 与 protobuf 之间的转换
+//
+// snowflake.proto 定义了 SnowflakeID 消息与字段选项 (snowflake.id), 其他 proto 文件通过
+// import "snowflake.proto" 使用:
+//
+//	message Order {
+//	  int64 id = 1 [(snowflake.id) = true];
+//	  snowflake.SnowflakeID user = 2;
+//	}
+//
+// 标注的字段可以由 protoc-gen-go-snowflake 生成 snowflake.ID 类型的访问方法
+package snowflakepb
+
+import (
+	"github.com/ming913/snowflake"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// 使用 id 创建 SnowflakeID
+func New(id snowflake.ID) *SnowflakeID {
+	return &SnowflakeID{Value: int64(id)}
+}
+
+// 返回保存的 snowflake.ID, x 为nil时返回0
+func (x *SnowflakeID) ID() snowflake.ID {
+	return snowflake.ID(x.GetValue())
+}
+
+// 设置保存的 snowflake.ID
+func (x *SnowflakeID) SetID(id snowflake.ID) {
+	x.Value = int64(id)
+}
+
+// 字段是否标注了 [(snowflake.id) = true]
+func IsID(fd protoreflect.FieldDescriptor) bool {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil {
+		return false
+	}
+	return proto.GetExtension(opts, E_Id).(bool)
+}
@@ -0,0 +1,23 @@
+package snowflake
+
+import "time"
+
+// Sonyflake 的缺省起始时间: 2014-09-01T00:00:00Z
+const SonyflakeEpoch int64 = 1409529600000
+
+// 与 sony/sonyflake 兼容的布局, 39位时间戳(10ms), 8位自增序列, 16位机器节点
+// 从高到低依次为: 时间戳, 自增序列, 机器节点
+var SonyflakeLayout = Layout{
+	TimeBits:    39,
+	MachineBits: 16,
+	StepBits:    8,
+	Epoch:       SonyflakeEpoch,
+	Unit:        10 * time.Millisecond,
+	MachineLast: true,
+}
+
+// 返回一个生成 Sonyflake 兼容ID的 Node, 解析ID请使用 SonyflakeLayout
+// opts 在 SonyflakeLayout 之后应用, 可以通过 WithEpoch 指定 sonyflake.Settings.StartTime
+func NewSonyflakeNode(machineID int64, opts ...Option) (*Node, error) {
+	return NewNode(machineID, append([]Option{WithLayout(SonyflakeLayout)}, opts...)...)
+}
@@ -0,0 +1,47 @@
+package snowflake
+
+import "fmt"
+
+// 实现 cloud.google.com/go/spanner 的 Encoder, 不需要引入 spanner
+// 写入为 INT64 列, 也可以作为 spanner.Key 的组成部分, 例如 spanner.Key{userID, orderID}
+func (f ID) EncodeSpanner() (interface{}, error) {
+	return int64(f), nil
+}
+
+// 实现 spanner.Decoder, 客户端以10进制字符串传入 INT64 列的值
+func (f *ID) DecodeSpanner(input interface{}) error {
+	switch v := input.(type) {
+	case string:
+		return f.UnmarshalText([]byte(v))
+	case *string:
+		if v == nil {
+			return fmt.Errorf("cannot decode NULL into snowflake.ID, use snowflake.NullID")
+		}
+		return f.UnmarshalText([]byte(*v))
+	case int64:
+		*f = ID(v)
+		return nil
+	}
+	return fmt.Errorf("cannot decode %T into snowflake.ID", input)
+}
+
+// 实现 spanner.Encoder, 为空时写入 INT64 类型的 NULL
+func (n NullID) EncodeSpanner() (interface{}, error) {
+	if !n.Valid {
+		return (*int64)(nil), nil
+	}
+	return int64(n.ID), nil
+}
+
+// 实现 spanner.Decoder, NULL 时 Valid 为 false
+func (n *NullID) DecodeSpanner(input interface{}) error {
+	if v, ok := input.(*string); input == nil || ok && v == nil {
+		*n = NullID{}
+		return nil
+	}
+	if err := n.ID.DecodeSpanner(input); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
@@ -0,0 +1,116 @@
+package snowflake
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// 实现 sql.Scanner, 支持 int64, uint64, []byte 与 string 类型的列值, 字符串为10进制
+// 同时接受 ID 与 *ID, ent 批量创建时会使用已设置的ID调用 Scan
+func (f *ID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case ID:
+		*f = v
+		return nil
+	case *ID:
+		if v != nil {
+			*f = *v
+			return nil
+		}
+	case int64:
+		*f = ID(v)
+		return nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return fmt.Errorf("%w: snowflake ID %d", ErrOverflow, v)
+		}
+		*f = ID(v)
+		return nil
+	case []byte:
+		return f.UnmarshalText(v)
+	case string:
+		return f.UnmarshalText([]byte(v))
+	}
+	return fmt.Errorf("cannot scan %T into snowflake.ID", src)
+}
+
+// 实现 driver.Valuer, 存储为 int64, 适用于 BIGINT 列
+func (f ID) Value() (driver.Value, error) {
+	return int64(f), nil
+}
+
+// 实现 sql.Scanner, 支持 int64, uint64, []byte 与 string 类型的列值
+// int64 按位转换, 以便读取驱动按有符号返回的 BIGINT UNSIGNED 列
+func (u *UnsignedID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*u = UnsignedID(v)
+		return nil
+	case uint64:
+		*u = UnsignedID(v)
+		return nil
+	case []byte:
+		return u.UnmarshalText(v)
+	case string:
+		return u.UnmarshalText([]byte(v))
+	}
+	return fmt.Errorf("cannot scan %T into snowflake.UnsignedID", src)
+}
+
+// 实现 driver.Valuer, 不超过 math.MaxInt64 时存储为 int64, 否则为10进制字符串,
+// 适用于 MySQL 的 BIGINT UNSIGNED 列
+func (u UnsignedID) Value() (driver.Value, error) {
+	if u > math.MaxInt64 {
+		return strconv.FormatUint(uint64(u), 10), nil
+	}
+	return int64(u), nil
+}
+
+// 可以为 NULL 的ID, 与 sql.NullInt64 相同, 用于可选的外键等列
+// JSON 中 NULL 编码为 null, 其余与 ID 相同
+type NullID struct {
+	ID    ID
+	Valid bool // ID 不为 NULL 时为 true
+}
+
+// 实现 sql.Scanner, NULL 时 Valid 为 false
+func (n *NullID) Scan(src interface{}) error {
+	if src == nil {
+		n.ID, n.Valid = 0, false
+		return nil
+	}
+	if err := n.ID.Scan(src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// 实现 driver.Valuer, Valid 为 false 时为 NULL
+func (n NullID) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.ID.Value()
+}
+
+func (n NullID) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return n.ID.MarshalJSON()
+}
+
+func (n *NullID) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		n.ID, n.Valid = 0, false
+		return nil
+	}
+	if err := n.ID.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
@@ -0,0 +1,32 @@
+package snowflake
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Node 的运行状态
+type Stats struct {
+	// 突发模式可以借用的未来时间
+	BorrowBudget time.Duration
+
+	// 逻辑时钟超前实际时间的时长, 未超前时为0
+	Drift time.Duration
+
+	// 累计借用的时间单位数量
+	Borrowed uint64
+}
+
+// 返回当前的运行状态
+func (n *Node) Stats() Stats {
+	last := atomic.LoadInt64(&n.state)>>n.stepBits + n.epoch
+
+	s := Stats{
+		BorrowBudget: time.Duration(n.budget) * n.unit,
+		Borrowed:     atomic.LoadUint64(&n.borrowed),
+	}
+	if ahead := last - n.clock.Now(); ahead > 0 {
+		s.Drift = time.Duration(ahead) * n.unit
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package snowflake
+
+import "context"
+
+// 返回一个由后台goroutine持续填充的ID通道, 缓冲区大小为 bufferSize
+// ctx 取消之后停止生成并关闭通道, 缓冲区中已生成的ID仍然可以读取
+// 生成出错时(例如 ReturnError 策略下的时钟回退)同样会关闭通道
+func (n *Node) Stream(ctx context.Context, bufferSize int) <-chan ID {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+
+	ch := make(chan ID, bufferSize)
+	go func() {
+		defer close(ch)
+
+		for {
+			id, err := n.GenerateContext(ctx)
+			if err != nil {
+				return
+			}
+
+			select {
+			case ch <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
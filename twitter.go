@@ -0,0 +1,22 @@
+package snowflake
+
+import "fmt"
+
+// 返回一个使用独立数据中心与 worker 编号的 Node, 兼容 Twitter 原始实现的 41/5/5/12 划分
+// 机器节点 = datacenterID << (MachineBits - RegionBits - DatacenterBits) | workerID
+func NewTwitterNode(datacenterID, workerID int64, opts ...Option) (*Node, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l := newLayout(c.Layout)
+	if max := int64(1)<<c.Layout.DatacenterBits - 1; datacenterID < 0 || datacenterID > max {
+		return nil, fmt.Errorf("%w: DatacenterID must be between 0 and %d", ErrMachineIDOutOfRange, max)
+	}
+	if workerID < 0 || workerID > l.workerMask {
+		return nil, fmt.Errorf("%w: WorkerID must be between 0 and %d", ErrMachineIDOutOfRange, l.workerMask)
+	}
+
+	return NewNode(datacenterID<<l.workerBits|workerID, opts...)
+}
@@ -0,0 +1,217 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// 无符号的 snowflake ID, 取值范围 [0, 2^64)
+// 用于 Layout.Unsigned 为 true 的布局, 以及使用无符号 bigint 存储ID的数据库
+type UnsignedID uint64
+
+// 按位转换为有符号ID, 用于按照 Layout 分解
+func (u UnsignedID) ID() ID {
+	return ID(u)
+}
+
+func (u UnsignedID) Uint64() uint64 {
+	return uint64(u)
+}
+
+// 转换为10进制字符串
+func (u UnsignedID) String() string {
+	return strconv.FormatUint(uint64(u), 10)
+}
+
+func (u UnsignedID) Base2() string {
+	return strconv.FormatUint(uint64(u), 2)
+}
+
+func (u UnsignedID) Base36() string {
+	return strconv.FormatUint(uint64(u), 36)
+}
+
+// 编码为固定16个字符的小写16进制, 参考 ID.Hex
+func (u UnsignedID) Hex() string {
+	return string(appendHex(make([]byte, 0, 16), uint64(u)))
+}
+
+func (u UnsignedID) Base32() string {
+	return string(appendUintBase(nil, uint64(u), encodeBase32Map))
+}
+
+func (u UnsignedID) Base58() string {
+	return string(appendUintBase(nil, uint64(u), encodeBase58Map))
+}
+
+func (u UnsignedID) Base62() string {
+	return string(appendUintBase(nil, uint64(u), encodeBase62Map))
+}
+
+func (u UnsignedID) Bytes() []byte {
+	return []byte(u.String())
+}
+
+func (u UnsignedID) IntBytes() [8]byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(u))
+	return b
+}
+
+// 编码格式与 ID.MarshalJSON 相同
+func (u UnsignedID) MarshalJSON() ([]byte, error) {
+	return u.appendJSON(make([]byte, 0, 22)), nil
+}
+
+// 把 MarshalJSON 的结果追加到 b
+func (u UnsignedID) appendJSON(b []byte) []byte {
+	if JSONAsNumber {
+		return strconv.AppendUint(b, uint64(u), 10)
+	}
+	if JSONStringEncoding != JSONDecimal {
+		return appendJSONString(b, uint64(u))
+	}
+
+	b = append(b, '"')
+	b = strconv.AppendUint(b, uint64(u), 10)
+	return append(b, '"')
+}
+
+// 接受的格式与 ID.UnmarshalJSON 相同
+func (u *UnsignedID) UnmarshalJSON(b []byte) error {
+	text, encoded, err := jsonText(b)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		*u = 0
+		return nil
+	}
+	if encoded {
+		v, err := parseJSONString(text)
+		*u = UnsignedID(v)
+		return err
+	}
+	return u.UnmarshalText(text)
+}
+
+// 编码为10进制文本, 参考 ID.MarshalText
+func (u UnsignedID) MarshalText() ([]byte, error) {
+	return strconv.AppendUint(make([]byte, 0, 20), uint64(u), 10), nil
+}
+
+// 解析10进制文本
+func (u *UnsignedID) UnmarshalText(b []byte) error {
+	i, err := ParseUnsignedString(string(b))
+	if err != nil {
+		return err
+	}
+
+	*u = i
+	return nil
+}
+
+// 编码为8字节大端序, 参考 ID.MarshalBinary
+func (u UnsignedID) MarshalBinary() ([]byte, error) {
+	b := u.IntBytes()
+	return b[:], nil
+}
+
+// 解析8字节大端序, 长度不是8时返回 ErrInvalidBinary
+func (u *UnsignedID) UnmarshalBinary(b []byte) error {
+	if len(b) != 8 {
+		return fmt.Errorf("%w: length %d", ErrInvalidBinary, len(b))
+	}
+
+	*u = UnsignedID(binary.BigEndian.Uint64(b))
+	return nil
+}
+
+// 解析10进制字符串
+func ParseUnsignedString(s string) (UnsignedID, error) {
+	return parseUnsignedInt(s, 10)
+}
+
+func ParseUnsignedBase2(s string) (UnsignedID, error) {
+	return parseUnsignedInt(s, 2)
+}
+
+func ParseUnsignedBase36(s string) (UnsignedID, error) {
+	return parseUnsignedInt(s, 36)
+}
+
+// 解析16进制, 参考 ParseHex
+func ParseUnsignedHex(s string) (UnsignedID, error) {
+	u, err := parseHex(s)
+	return UnsignedID(u), err
+}
+
+func ParseUnsignedBase32(b []byte) (UnsignedID, error) {
+	u, err := parseUintBase(b, &decodeBase32Map, 32, ErrInvalidBase32)
+	return UnsignedID(u), err
+}
+
+func ParseUnsignedBase58(b []byte) (UnsignedID, error) {
+	u, err := parseUintBase(b, &decodeBase58Map, 58, ErrInvalidBase58)
+	return UnsignedID(u), err
+}
+
+func ParseUnsignedBase62(b []byte) (UnsignedID, error) {
+	u, err := parseUintBase(b, &decodeBase62Map, 62, ErrInvalidBase62)
+	return UnsignedID(u), err
+}
+
+func parseUnsignedInt(s string, base int) (UnsignedID, error) {
+	u, err := strconv.ParseUint(s, base, 64)
+	if err != nil {
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+		}
+		return 0, err
+	}
+	return UnsignedID(u), nil
+}
+
+// 把 v 按照 alphabet 对应的进制编码之后追加到 dst
+func appendUintBase(dst []byte, v uint64, alphabet string) []byte {
+	base := uint64(len(alphabet))
+	if v < base {
+		return append(dst, alphabet[v])
+	}
+
+	var b [64]byte
+	i := len(b)
+	for v >= base {
+		i--
+		b[i] = alphabet[v%base]
+		v /= base
+	}
+	i--
+	b[i] = alphabet[v]
+
+	return append(dst, b[i:]...)
+}
+
+// 按照 decode 对应的进制解析 b, 非法字符返回 invalid, 超出 uint64 范围返回 ErrOverflow
+func parseUintBase(b []byte, decode *[128]byte, base uint64, invalid error) (uint64, error) {
+	if len(b) == 0 {
+		return 0, invalid
+	}
+
+	var v uint64
+	for i := range b {
+		if b[i] >= 128 || decode[b[i]] == 0xFF {
+			return 0, invalid
+		}
+
+		d := uint64(decode[b[i]])
+		if v > (math.MaxUint64-d)/base {
+			return 0, fmt.Errorf("%w: %q", ErrOverflow, b)
+		}
+		v = v*base + d
+	}
+
+	return v, nil
+}
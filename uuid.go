@@ -0,0 +1,142 @@
+package snowflake
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// RFC 9562 UUID
+type UUID [16]byte
+
+// UUIDv7 中可以自由使用的位数: rand_a(12) + rand_b(62)
+const uuidRandBits = 74
+
+// 生成 UUIDv7, 时间戳与唯一性由 Node 保证
+// rand_a 与 rand_b 的高位依次放入ID中时间戳之后的各部分(租户, 机器节点, 自增序列), 其余低位为随机数
+// 同一 Node 同一毫秒内生成的 UUID 递增, Layout.Unit 必须为毫秒
+// 等待与错误处理与 GenerateContext 相同, 不会 panic
+func (n *Node) GenerateUUIDv7() (UUID, error) {
+	if n.unit != time.Millisecond {
+		return UUID{}, fmt.Errorf("%w: UUIDv7 requires millisecond unit", ErrInvalidLayout)
+	}
+
+	id, err := n.generate(context.Background(), waitPolicy, 0)
+	if err != nil {
+		return UUID{}, err
+	}
+	u, err := n.layout.toUUIDv7(id)
+	if err != nil {
+		return u, err
+	}
+
+	// 剩余的低位填充随机数
+	var r [8]byte
+	if _, err := rand.Read(r[:]); err != nil {
+		return UUID{}, err
+	}
+	free := uuidRandBits - uint(n.timeShift)
+	if free > 62 {
+		free = 62
+	}
+	lo := binary.BigEndian.Uint64(u[8:16]) | binary.BigEndian.Uint64(r[:])&(1<<free-1)
+	binary.BigEndian.PutUint64(u[8:16], lo)
+
+	return u, nil
+}
+
+// 把ID转换为 UUIDv7, 保留时间戳以及之后的各部分, 随机位为0
+// Layout.Unit 必须为毫秒
+func (l Layout) ToUUIDv7(id ID) (UUID, error) {
+	if l.unit() != time.Millisecond {
+		return UUID{}, fmt.Errorf("%w: UUIDv7 requires millisecond unit", ErrInvalidLayout)
+	}
+	return newLayout(l).toUUIDv7(id)
+}
+
+// 由 ToUUIDv7 或 Node.GenerateUUIDv7 生成的 UUIDv7 还原ID
+func (l Layout) FromUUIDv7(u UUID) (ID, error) {
+	if u.Version() != 7 {
+		return -1, fmt.Errorf("%w: version %d", ErrInvalidUUID, u.Version())
+	}
+	if l.unit() != time.Millisecond {
+		return -1, fmt.Errorf("%w: UUIDv7 requires millisecond unit", ErrInvalidLayout)
+	}
+
+	c := newLayout(l)
+	t := u.Time()
+	if t < c.epoch || t-c.epoch > c.timeMask {
+		return -1, fmt.Errorf("%w: time %d out of range", ErrOverflow, t)
+	}
+
+	// 74位可用位中的高 timeShift 位
+	hi := binary.BigEndian.Uint64(u[0:8])
+	lo := binary.BigEndian.Uint64(u[8:16])
+	v := (hi&0xFFF)<<52 | (lo&(1<<62-1))>>10
+	v >>= 64 - uint(c.timeShift)
+
+	return ID(int64(c.versionTag) | (t-c.epoch)<<c.timeShift | int64(v)), nil
+}
+
+func (l layout) toUUIDv7(id ID) (UUID, error) {
+	var u UUID
+
+	ts := l.time(id)
+	if ts < 0 || ts >= 1<<48 {
+		return u, fmt.Errorf("%w: time %d out of UUIDv7 range", ErrOverflow, ts)
+	}
+
+	// 时间戳之后的各部分放在74位可用位的最高位
+	v := uint64(id) << (64 - uint(l.timeShift))
+	if l.timeShift == 0 {
+		v = 0
+	}
+
+	hi := uint64(ts)<<16 | 7<<12 | v>>52
+	lo := 2<<62 | v<<12>>2
+	binary.BigEndian.PutUint64(u[0:8], hi)
+	binary.BigEndian.PutUint64(u[8:16], lo)
+	return u, nil
+}
+
+// UUID 的版本号
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// UUIDv7 中的时间戳, 单位: 毫秒(ms)
+func (u UUID) Time() int64 {
+	return int64(binary.BigEndian.Uint64(u[0:8]) >> 16)
+}
+
+// 转换为 xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx 格式的字符串
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// 解析 xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx 格式的字符串
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, ErrInvalidUUID
+	}
+
+	src := []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+	if _, err := hex.Decode(u[:], src); err != nil {
+		return u, ErrInvalidUUID
+	}
+	return u, nil
+}
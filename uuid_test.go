@@ -0,0 +1,136 @@
+package snowflake
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 回归测试: 已关闭的 Node 返回错误而不是 panic
+func TestGenerateUUIDv7Closed(t *testing.T) {
+	node, err := NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Close()
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("GenerateUUIDv7 panicked: %v", r)
+		}
+	}()
+	if _, err := node.GenerateUUIDv7(); !errors.Is(err, ErrNodeClosed) {
+		t.Errorf("got %v, want ErrNodeClosed", err)
+	}
+}
+
+func TestGenerateUUIDv7Unit(t *testing.T) {
+	node, err := NewNode(1, WithLayout(MicrosecondLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if _, err := node.GenerateUUIDv7(); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("got %v, want ErrInvalidLayout", err)
+	}
+	if _, err := MicrosecondLayout.ToUUIDv7(1); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("ToUUIDv7: got %v, want ErrInvalidLayout", err)
+	}
+}
+
+func TestGenerateUUIDv7(t *testing.T) {
+	node, err := NewNode(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	var prev UUID
+	for i := 0; i < 1000; i++ {
+		u, err := node.GenerateUUIDv7()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Version() != 7 || u[8]>>6 != 2 {
+			t.Fatalf("%s: got version %d variant %d, want 7 and 2", u, u.Version(), u[8]>>6)
+		}
+		if d := time.Since(time.UnixMilli(u.Time())); d < 0 || d > time.Minute {
+			t.Fatalf("%s: time %d is not the current time", u, u.Time())
+		}
+		if bytes.Compare(prev[:], u[:]) >= 0 {
+			t.Fatalf("%s is not greater than %s", u, prev)
+		}
+		prev = u
+
+		id, err := node.Layout().FromUUIDv7(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Machine(id) != 3 || node.Time(id) != u.Time() {
+			t.Fatalf("FromUUIDv7(%s): got machine %d time %d, want 3 and %d", u, node.Machine(id), node.Time(id), u.Time())
+		}
+	}
+}
+
+func TestUUIDv7RoundTrip(t *testing.T) {
+	node, err := NewNode(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	l := node.Layout()
+	for i := 0; i < 100; i++ {
+		id := node.Generate()
+		u, err := l.ToUUIDv7(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseUUID(u.String())
+		if err != nil || parsed != u {
+			t.Fatalf("ParseUUID(%q): got (%s, %v), want %s", u.String(), parsed, err, u)
+		}
+		got, err := l.FromUUIDv7(parsed)
+		if err != nil || got != id {
+			t.Fatalf("FromUUIDv7(%s): got (%d, %v), want %d", u, got, err, id)
+		}
+	}
+}
+
+func TestFromUUIDv7Invalid(t *testing.T) {
+	l := DefaultLayout()
+
+	v4, err := ParseUUID("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.FromUUIDv7(v4); !errors.Is(err, ErrInvalidUUID) {
+		t.Errorf("version 4: got %v, want ErrInvalidUUID", err)
+	}
+
+	// 早于 Epoch 的时间
+	early, err := ParseUUID("00000000-0001-7000-8000-000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.FromUUIDv7(early); !errors.Is(err, ErrOverflow) {
+		t.Errorf("time before epoch: got %v, want ErrOverflow", err)
+	}
+}
+
+func TestParseUUIDInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"f47ac10b58cc4372a5670e02b2c3d479",
+		"f47ac10b-58cc-4372-a567-0e02b2c3d47",
+		"f47ac10b-58cc-4372-a567_0e02b2c3d479",
+		"f47ac10b-58cc-4372-a567-0e02b2c3d47g",
+		"{47ac10b-58cc-4372-a567-0e02b2c3d479}",
+	} {
+		if _, err := ParseUUID(s); !errors.Is(err, ErrInvalidUUID) {
+			t.Errorf("ParseUUID(%q): got %v, want ErrInvalidUUID", s, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package snowflake
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// yaml.v3 的 *yaml.Node 实现了该接口, 不需要引入 yaml
+type yamlNode interface {
+	Decode(v interface{}) error
+}
+
+// 实现 gopkg.in/yaml.v3 与 yaml.v2 的 Marshaler, 不需要引入 yaml
+// 编码为10进制字符串, 输出时带引号, 以免被其他工具按照浮点数解析而丢失精度
+func (f ID) MarshalYAML() (interface{}, error) {
+	return f.String(), nil
+}
+
+// 实现 yaml.v2 的 Unmarshaler, yaml.v3 同样按照该形式调用, 接受带引号与不带引号的10进制数字
+// 不带引号时按照 yaml 解析出的整数处理, 例如 0x10 为16
+func (f *ID) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	id, ok, err := unmarshalYAML(unmarshal)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid snowflake ID: null")
+	}
+	*f = id
+	return nil
+}
+
+// 从 yaml.v3 的 *yaml.Node 解析, 用于自定义 UnmarshalYAML(*yaml.Node) 中的字段, 与 UnmarshalYAML 相同
+func (f *ID) UnmarshalYAMLNode(node yamlNode) error {
+	return f.UnmarshalYAML(node.Decode)
+}
+
+// 实现 yaml Marshaler, Valid 为 false 时为 null
+func (n NullID) MarshalYAML() (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.ID.MarshalYAML()
+}
+
+// 实现 yaml Unmarshaler, null 或空值时 Valid 为 false
+func (n *NullID) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	id, ok, err := unmarshalYAML(unmarshal)
+	if err != nil {
+		return err
+	}
+	n.ID, n.Valid = id, ok
+	return nil
+}
+
+// 从 yaml.v3 的 *yaml.Node 解析, 与 UnmarshalYAML 相同
+func (n *NullID) UnmarshalYAMLNode(node yamlNode) error {
+	return n.UnmarshalYAML(node.Decode)
+}
+
+// 按照 yaml 解析出的类型处理, null 或空字符串时返回 false
+func unmarshalYAML(unmarshal func(interface{}) error) (ID, bool, error) {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return 0, false, err
+	}
+
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return 0, false, nil
+	case string:
+		if v == "" {
+			return 0, false, nil
+		}
+		s = v
+	case int:
+		return ID(v), true, nil
+	case int64:
+		return ID(v), true, nil
+	case uint64:
+		s = strconv.FormatUint(v, 10)
+	default:
+		return 0, false, fmt.Errorf("invalid snowflake ID %v", v)
+	}
+
+	var id ID
+	if err := id.UnmarshalText([]byte(s)); err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
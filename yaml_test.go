@@ -0,0 +1,68 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+)
+
+// 模拟 yaml 的解析结果, 与 yaml.v3 的 *yaml.Node 一样实现 Decode
+type yamlValue struct{ v interface{} }
+
+func (y yamlValue) Decode(out interface{}) error {
+	*out.(*interface{}) = y.v
+	return nil
+}
+
+func TestUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  NullID
+		err   error
+	}{
+		{"quoted", "1234567890123", NullID{ID: 1234567890123, Valid: true}, nil},
+		{"int", 1234567890123, NullID{ID: 1234567890123, Valid: true}, nil},
+		{"int64", int64(-1), NullID{ID: -1, Valid: true}, nil},
+		{"null", nil, NullID{}, nil},
+		{"empty", "", NullID{}, nil},
+		{"uint64 overflow", uint64(1 << 63), NullID{}, ErrOverflow},
+		{"string overflow", "9223372036854775808", NullID{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NullID{ID: 7, Valid: true}
+			err := n.UnmarshalYAMLNode(yamlValue{tt.value})
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil || n != tt.want {
+				t.Fatalf("got (%+v, %v), want %+v", n, err, tt.want)
+			}
+
+			var id ID
+			err = id.UnmarshalYAML(yamlValue{tt.value}.Decode)
+			if tt.want.Valid != (err == nil) || err == nil && id != tt.want.ID {
+				t.Errorf("ID: got (%d, %v), want %+v", id, err, tt.want)
+			}
+		})
+	}
+
+	for _, v := range []interface{}{1.5, []interface{}{1}, "abc"} {
+		var id ID
+		if err := id.UnmarshalYAMLNode(yamlValue{v}); err == nil {
+			t.Errorf("%v: got %d, want an error", v, id)
+		}
+	}
+}
+
+func TestMarshalYAML(t *testing.T) {
+	if v, err := ID(42).MarshalYAML(); err != nil || v != "42" {
+		t.Errorf("ID: got (%v, %v), want \"42\"", v, err)
+	}
+	if v, err := (NullID{}).MarshalYAML(); err != nil || v != nil {
+		t.Errorf("NullID: got (%v, %v), want nil", v, err)
+	}
+}